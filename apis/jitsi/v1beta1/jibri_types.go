@@ -20,10 +20,13 @@ type JibriSpec struct {
 // JibriStatus defines the observed state of JibriSpec.
 type JibriStatus struct {
 	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 
 // Jibri is the Schema for the Jibri API.
 type Jibri struct {
@@ -15,10 +15,13 @@ type JicofoSpec struct {
 // JicofoStatus defines the observed state of JicofoSpec.
 type JicofoStatus struct {
 	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 
 // Jicofo is the Schema for the jicodo API.
 type Jicofo struct {
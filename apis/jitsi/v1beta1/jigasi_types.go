@@ -20,10 +20,13 @@ type JigasiSpec struct {
 // JigasiStatus defines the observed state of Jigasi.
 type JigasiStatus struct {
 	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 
 // Jigasi is the Schema for the Jigasi API.
 type Jigasi struct {
@@ -6,8 +6,34 @@ package v1beta1
 import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// BridgeMode defines how the operator manages the bridges.
+type BridgeMode string
+
+const (
+	// BridgeModeDeployment manages every bridge as a separate Deployment jvb-N.
+	BridgeModeDeployment BridgeMode = "Deployment"
+	// BridgeModeStatefulSet manages the bridges as the pods jvb-0..jvb-N-1 of one StatefulSet.
+	BridgeModeStatefulSet BridgeMode = "StatefulSet"
+)
+
+// NetworkMode defines how the media port of the bridges is reached.
+type NetworkMode string
+
+const (
+	// NetworkModeService exposes every bridge by its own Service.
+	NetworkModeService NetworkMode = "Service"
+	// NetworkModeHostNetwork runs the bridges in the network namespace of their node.
+	NetworkModeHostNetwork NetworkMode = "HostNetwork"
+	// NetworkModeHostPort maps the media port of the bridges to the same port of their node.
+	NetworkModeHostPort NetworkMode = "HostPort"
+)
+
+// The pods of a StatefulSet share the host port of the template and the hostname of their node, so the host
+// network modes are only supported in Deployment mode.
+// +kubebuilder:validation:XValidation:rule="self.mode != 'StatefulSet' || self.networkMode == 'Service'",message="networkMode must be Service in StatefulSet mode"
 type JVBSpec struct {
 	DeploymentSpec     `json:",inline"`
 	Exporter           Exporter          `json:"exporter,omitempty"`
@@ -16,15 +42,96 @@ type JVBSpec struct {
 	//+kubebuilder:default:="ClusterIP"
 	ServiceType v1.ServiceType `json:"service_type,omitempty"`
 	Port        Port           `json:"port,omitempty"`
+	// DrainTimeout is how long a bridge removed by scale down may host conferences in graceful
+	// shutdown before it is deleted anyway, "1h" by default.
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+	// Mode is Deployment by default, it can't be changed once the JVB is created.
+	//+kubebuilder:validation:Enum=Deployment;StatefulSet
+	//+kubebuilder:validation:XValidation:rule="self == oldSelf",message="mode is immutable"
+	//+kubebuilder:default:="Deployment"
+	Mode BridgeMode `json:"mode,omitempty"`
+	// MaxUnavailable is the number or percentage of bridges which are drained and updated at once
+	// when the pod template changes, 1 by default.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// NetworkMode is Service by default. In HostNetwork and HostPort mode every bridge runs on its own node
+	// and announces the ExternalIP of the node, no Service is created for the media port.
+	//+kubebuilder:validation:Enum=Service;HostNetwork;HostPort
+	//+kubebuilder:default:="Service"
+	NetworkMode NetworkMode `json:"networkMode,omitempty"`
+	// IPFamilyPolicy of the bridge Services, PreferDualStack or RequireDualStack announce an address per IP family.
+	IPFamilyPolicy *v1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+	// IPFamilies of the bridge Services, the first family is the one of DOCKER_HOST_ADDRESS.
+	//+kubebuilder:validation:MaxItems=2
+	IPFamilies []v1.IPFamily `json:"ipFamilies,omitempty"`
 }
 
 // JVBStatus defines the observed state of JVBSpec.
 type JVBStatus struct {
 	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
+	// Draining are the bridges in graceful shutdown, they are deleted once they host no conferences.
+	Draining []BridgeDrain `json:"draining,omitempty"`
+	// Rollout is the progress of the update of the bridges to the current spec.
+	Rollout *BridgeRollout `json:"rollout,omitempty"`
+	// Addresses are the public addresses of the bridges discovered from their LoadBalancer Services.
+	Addresses []BridgeAddress `json:"addresses,omitempty"`
+}
+
+// BridgeAddress is the public address announced by a bridge to the clients.
+type BridgeAddress struct {
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+	// SecondaryIP is the address of the other IP family of a dual-stack Service.
+	SecondaryIP string `json:"secondaryIP,omitempty"`
+}
+
+// BridgeDrain describes a bridge which was removed by scale down and waits for its conferences to end.
+type BridgeDrain struct {
+	Name      string      `json:"name"`
+	StartTime metav1.Time `json:"startTime"`
+	// Conferences is the conference count of the bridge observed during the last reconciliation.
+	Conferences int32 `json:"conferences"`
+	// ShutdownRequested is set once the bridge accepted the graceful shutdown request. The bridge exits after
+	// its last conference ended, so it counts as drained once it isn't reachable anymore.
+	ShutdownRequested bool `json:"shutdownRequested,omitempty"`
+}
+
+// BridgeRollout describes the progress of the rolling update of the bridges.
+type BridgeRollout struct {
+	// Revision identifies the spec the bridges are updated to.
+	Revision string `json:"revision"`
+	// UpdatedReplicas is the number of bridges running the revision.
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// OutdatedReplicas is the number of bridges waiting for the update.
+	OutdatedReplicas int32 `json:"outdatedReplicas"`
+	// Upgrading are the bridges which are drained or restarted with the revision.
+	Upgrading []BridgeUpgrade `json:"upgrading,omitempty"`
+	// Paused is set if an updated bridge failed its health check, the rollout continues once the spec changes.
+	Paused bool `json:"paused,omitempty"`
+	// Message describes why the rollout is paused.
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradePhase is the step of the update of a single bridge.
+type UpgradePhase string
+
+const (
+	// UpgradePhaseDraining waits until the bridge in graceful shutdown hosts no conferences.
+	UpgradePhaseDraining UpgradePhase = "Draining"
+	// UpgradePhaseUpdating waits until the bridge restarted with the revision is healthy.
+	UpgradePhaseUpdating UpgradePhase = "Updating"
+)
+
+// BridgeUpgrade describes a bridge which is updated by the rollout.
+type BridgeUpgrade struct {
+	BridgeDrain `json:",inline"`
+	Phase       UpgradePhase `json:"phase"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 //+kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=".status.replicas"
 
 // JVB is the Schema for the JVB API.
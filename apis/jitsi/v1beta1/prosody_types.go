@@ -19,10 +19,13 @@ type ProsodySpec struct {
 // ProsodyStatus defines the observed state of Prosody.
 type ProsodyStatus struct {
 	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 
 // Prosody is the Schema for the Prosody API.
 type Prosody struct {
@@ -9,9 +9,80 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BridgeAddress) DeepCopyInto(out *BridgeAddress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BridgeAddress.
+func (in *BridgeAddress) DeepCopy() *BridgeAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(BridgeAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BridgeDrain) DeepCopyInto(out *BridgeDrain) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BridgeDrain.
+func (in *BridgeDrain) DeepCopy() *BridgeDrain {
+	if in == nil {
+		return nil
+	}
+	out := new(BridgeDrain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BridgeRollout) DeepCopyInto(out *BridgeRollout) {
+	*out = *in
+	if in.Upgrading != nil {
+		in, out := &in.Upgrading, &out.Upgrading
+		*out = make([]BridgeUpgrade, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BridgeRollout.
+func (in *BridgeRollout) DeepCopy() *BridgeRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(BridgeRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BridgeUpgrade) DeepCopyInto(out *BridgeUpgrade) {
+	*out = *in
+	in.BridgeDrain.DeepCopyInto(&out.BridgeDrain)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BridgeUpgrade.
+func (in *BridgeUpgrade) DeepCopy() *BridgeUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(BridgeUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentSpec) DeepCopyInto(out *DeploymentSpec) {
 	*out = *in
@@ -78,7 +149,7 @@ func (in *JVB) DeepCopyInto(out *JVB) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JVB.
@@ -149,6 +220,26 @@ func (in *JVBSpec) DeepCopyInto(out *JVBSpec) {
 		}
 	}
 	out.Port = in.Port
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(v1.IPFamilyPolicy)
+		**out = **in
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]v1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JVBSpec.
@@ -164,6 +255,23 @@ func (in *JVBSpec) DeepCopy() *JVBSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JVBStatus) DeepCopyInto(out *JVBStatus) {
 	*out = *in
+	if in.Draining != nil {
+		in, out := &in.Draining, &out.Draining
+		*out = make([]BridgeDrain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(BridgeRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]BridgeAddress, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JVBStatus.
@@ -4,6 +4,8 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -13,42 +15,329 @@ const (
 	ResourceCPU          MetricName = "cpu"
 	ResourceConference   MetricName = "jitsi_conference"
 	ResourceParticipants MetricName = "jitsi_participants"
+	// ResourceStressLevel is the load of the bridge reported by itself, 1 means fully loaded.
+	ResourceStressLevel MetricName = "jitsi_stress_level"
+	// ResourceBitRateDownload and ResourceBitRateUpload are the bit rates of the bridge in kbit/s.
+	ResourceBitRateDownload MetricName = "jitsi_bit_rate_download"
+	ResourceBitRateUpload   MetricName = "jitsi_bit_rate_upload"
+	ResourceCustom          MetricName = "custom"
+)
+
+// Monitoring types, the VictoriaMetrics and Thanos types use the Prometheus query API.
+// Colibri reads the statistics of the bridges directly, without a monitoring system.
+const (
+	MonitoringPrometheus      = "prometheus"
+	MonitoringVictoriaMetrics = "victoriametrics"
+	MonitoringThanos          = "thanos"
+	MonitoringInfluxDB        = "influxdb"
+	MonitoringColibri         = "colibri"
+)
+
+// Reduction defines how the samples returned by a query are reduced to a single value.
+type Reduction string
+
+const (
+	ReductionAvg  Reduction = "avg"
+	ReductionMax  Reduction = "max"
+	ReductionSum  Reduction = "sum"
+	ReductionLast Reduction = "last"
+)
+
+// Mode defines whether the autoscaler updates the scale target.
+type Mode string
+
+const (
+	ModeEnforce Mode = "Enforce"
+	// ModeDryRun records the decisions in the status and events, but never updates the scale target.
+	ModeDryRun Mode = "DryRun"
 )
 
 // AutoScalerSpec defines the desired state of AutoScaler.
 type AutoScalerSpec struct {
 	Labels         map[string]string `json:"labels,omitempty"`
 	MonitoringType string            `json:"monitoringType,omitempty"`
-	Host           string            `json:"host"`
+	Host           string            `json:"host,omitempty"`
 	Interval       string            `json:"interval,omitempty"`
 	Auth           Auth              `json:"auth,omitempty"`
+	TLS            *TLSConfig        `json:"tls,omitempty"`
+	InfluxDB       *InfluxDB         `json:"influxdb,omitempty"`
+	Prometheus     *PrometheusAPI    `json:"prometheus,omitempty"`
 	ScaleTargetRef ScaleTargetRef    `json:"scaleTargetRef,omitempty"`
 	MinReplicas    int32             `json:"minReplicas,omitempty"`
 	MaxReplicas    int32             `json:"maxReplicas,omitempty"`
-	Metric         Metric            `json:"metric,omitempty"`
+	// Deprecated: use Metrics instead, Metric is used only if Metrics is empty.
+	Metric Metric `json:"metric,omitempty"`
+	// Metrics are used to calculate the desired replica count, the maximum replica count
+	// across all metrics will be used.
+	Metrics  []Metric  `json:"metrics,omitempty"`
+	Behavior *Behavior `json:"behavior,omitempty"`
+	// Schedules override the replica range while they are active, the overrides are applied
+	// before the metric based decision.
+	Schedules []Schedule `json:"schedules,omitempty"`
+	// Predictive scales to the forecast of the metrics instead of their current values, if it is higher.
+	Predictive *Predictive `json:"predictive,omitempty"`
+	// Aggregation filters the samples before they are reduced to the metric values.
+	Aggregation *Aggregation `json:"aggregation,omitempty"`
+	// Mode is Enforce by default, in DryRun the scale target is never updated.
+	//+kubebuilder:validation:Enum=Enforce;DryRun
+	//+kubebuilder:default:="Enforce"
+	Mode Mode `json:"mode,omitempty"`
 }
 
 // ScaleTargetRef contains enough information to let you identify the referred resource.
+// The resource must implement the scale subresource.
 type ScaleTargetRef struct {
+	//+kubebuilder:default:="jitsi.meeting.ko/v1beta1"
+	APIVersion string `json:"apiVersion,omitempty"`
+	//+kubebuilder:default:="JVB"
+	Kind string `json:"kind,omitempty"`
 	Name string `json:"name"`
 }
 
 type Metric struct {
 	Name                     MetricName `json:"name"`
-	TargetAverageUtilization int32      `json:"targetAverageUtilization"`
+	TargetAverageUtilization int32      `json:"targetAverageUtilization,omitempty"`
+	// Target is the target value of the metric, e.g. "0.8" for jitsi_stress_level. TargetAverageUtilization
+	// is used if not set.
+	Target *resource.Quantity `json:"target,omitempty"`
+	// Custom must be set if Name is "custom".
+	Custom *CustomMetric `json:"custom,omitempty"`
+}
+
+// CustomMetric is a user-defined query, PromQL for prometheus and Flux for influxdb.
+type CustomMetric struct {
+	Query string `json:"query"`
+	//+kubebuilder:validation:Enum=avg;max;sum;last
+	//+kubebuilder:default:="avg"
+	Reduction Reduction `json:"reduction,omitempty"`
+	// Target is the target value of the reduced query result, TargetAverageUtilization is used if not set.
+	Target *resource.Quantity `json:"target,omitempty"`
+}
+
+// Schedule overrides MinReplicas and MaxReplicas either for Duration after every Cron activation
+// or for the single calendar window between Start and End.
+type Schedule struct {
+	Name string `json:"name"`
+	// Cron is a standard five field cron expression, e.g. "30 8 * * 1" for Mondays at 08:30.
+	Cron string `json:"cron,omitempty"`
+	// Duration is how long the schedule stays active after each cron activation, e.g. "2h".
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// TimeZone is the IANA time zone name of the cron expression, UTC by default.
+	TimeZone string       `json:"timeZone,omitempty"`
+	Start    *metav1.Time `json:"start,omitempty"`
+	End      *metav1.Time `json:"end,omitempty"`
+	//+kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	//+kubebuilder:validation:Minimum=1
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// Aggregation configures which samples returned by the monitoring system are used. NaN and infinite
+// samples are always dropped. A metric without enough valid samples is treated as unavailable.
+type Aggregation struct {
+	// MaxSampleAge drops samples older than this, e.g. of bridges which are gone, "5m" by default. "0s" disables it.
+	MaxSampleAge *metav1.Duration `json:"maxSampleAge,omitempty"`
+	// MinSamples is the minimum number of valid samples, e.g. of reporting bridges, 1 by default.
+	//+kubebuilder:validation:Minimum=1
+	MinSamples int32 `json:"minSamples,omitempty"`
+}
+
+// Predictive configures the forecast of the metrics. The forecast is the linear trend of the
+// lookback window extrapolated by the horizon, plus the change the metric had during the same
+// time one week ago.
+type Predictive struct {
+	// Lookback is the window of recent samples the linear trend is fitted to, "1h" by default.
+	Lookback *metav1.Duration `json:"lookback,omitempty"`
+	// Horizon is how far ahead the forecast looks, it should cover the startup time of a bridge. "15m" by default.
+	Horizon *metav1.Duration `json:"horizon,omitempty"`
+	// HeadroomPercent is added on top of the forecast.
+	//+kubebuilder:validation:Minimum=0
+	HeadroomPercent int32 `json:"headroomPercent,omitempty"`
+}
+
+// Behavior configures the scaling behavior in both up and down directions, same as in autoscaling/v2.
+type Behavior struct {
+	ScaleUp   *ScalingRules `json:"scaleUp,omitempty"`
+	ScaleDown *ScalingRules `json:"scaleDown,omitempty"`
+}
+
+type ScalingPolicyType string
+
+const (
+	PodsScalingPolicy    ScalingPolicyType = "Pods"
+	PercentScalingPolicy ScalingPolicyType = "Percent"
+)
+
+type ScalingPolicySelect string
+
+const (
+	MaxChangePolicySelect ScalingPolicySelect = "Max"
+	MinChangePolicySelect ScalingPolicySelect = "Min"
+	DisabledPolicySelect  ScalingPolicySelect = "Disabled"
+)
+
+// ScalingRules configures the scaling behavior for one direction.
+type ScalingRules struct {
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=3600
+	StabilizationWindowSeconds *int32 `json:"stabilizationWindowSeconds,omitempty"`
+	//+kubebuilder:validation:Enum=Max;Min;Disabled
+	SelectPolicy *ScalingPolicySelect `json:"selectPolicy,omitempty"`
+	Policies     []ScalingPolicy      `json:"policies,omitempty"`
 }
 
+// ScalingPolicy is a single policy which must hold true for a specified past interval.
+type ScalingPolicy struct {
+	//+kubebuilder:validation:Enum=Pods;Percent
+	Type ScalingPolicyType `json:"type"`
+	//+kubebuilder:validation:Minimum=1
+	Value int32 `json:"value"`
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=1800
+	PeriodSeconds int32 `json:"periodSeconds"`
+}
+
+// Auth configures the credentials of the monitoring system. Secret references take precedence
+// over the plaintext fields, which are kept for compatibility only.
 type Auth struct {
 	Login    string `json:"login,omitempty"`
 	Password string `json:"password,omitempty"`
 	Token    string `json:"token,omitempty"`
+	// LoginSecretRef and PasswordSecretRef are used for basic auth, influxdb ignores them and uses only the token.
+	LoginSecretRef    *corev1.SecretKeySelector `json:"loginSecretRef,omitempty"`
+	PasswordSecretRef *corev1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
+	// TokenSecretRef is used as bearer token for prometheus and as auth token for influxdb.
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+	// Headers are added to every request to the monitoring system.
+	Headers []Header `json:"headers,omitempty"`
+}
+
+// InfluxDB configures where the jitsi metrics are stored in InfluxDB. Empty fields are taken from the
+// deprecated jas.influxdb/org and jas.influxdb/bucket annotations or fall back to the defaults.
+type InfluxDB struct {
+	// Org is either the organization name or the ID, "influxdata" by default.
+	//+kubebuilder:validation:MinLength=1
+	Org string `json:"org,omitempty"`
+	// Bucket with the jitsi metrics, "jitsi" by default.
+	//+kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket,omitempty"`
+	// Measurement with the jitsi metrics, "jitsi_stats" by default.
+	//+kubebuilder:validation:MinLength=1
+	Measurement string `json:"measurement,omitempty"`
+	// Fields maps the metric names to the fields of the measurement, e.g. jitsi_participants: participants.
+	Fields map[MetricName]string `json:"fields,omitempty"`
+	// Range is the Flux duration the metrics are queried for, "15m" by default.
+	//+kubebuilder:validation:Pattern=`^([0-9]+(ns|us|ms|s|m|h|d|w|mo|y))+$`
+	Range string `json:"range,omitempty"`
+}
+
+// PrometheusAPI configures the Prometheus compatible query API of the prometheus, victoriametrics and thanos types.
+type PrometheusAPI struct {
+	// PathPrefix is prepended to the /api/v1 query endpoints, e.g. "/select/0/prometheus" for the vmselect
+	// of a VictoriaMetrics cluster.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// Tenant is sent in the TenantHeader. For victoriametrics without a PathPrefix it is the account ID
+	// in the vmselect path instead.
+	Tenant string `json:"tenant,omitempty"`
+	// TenantHeader is the header the tenant is sent in, "THANOS-TENANT" for thanos and "X-Scope-OrgID" otherwise.
+	TenantHeader string `json:"tenantHeader,omitempty"`
+}
+
+// TLSConfig configures the TLS connection to the monitoring system.
+type TLSConfig struct {
+	// CA is the bundle of certificates used to verify the server certificate, system roots are used if not set.
+	CA *SecretOrConfigMap `json:"ca,omitempty"`
+	// ClientCertSecret is a secret of type kubernetes.io/tls with the client certificate and key.
+	ClientCertSecret   *corev1.LocalObjectReference `json:"clientCertSecret,omitempty"`
+	ServerName         string                       `json:"serverName,omitempty"`
+	InsecureSkipVerify bool                         `json:"insecureSkipVerify,omitempty"`
+}
+
+// SecretOrConfigMap references a key of either a secret or a config map.
+type SecretOrConfigMap struct {
+	Secret    *corev1.SecretKeySelector    `json:"secret,omitempty"`
+	ConfigMap *corev1.ConfigMapKeySelector `json:"configMap,omitempty"`
 }
 
+type Header struct {
+	Name      string                    `json:"name"`
+	Value     string                    `json:"value,omitempty"`
+	ValueFrom *corev1.SecretKeySelector `json:"valueFrom,omitempty"`
+}
+
+// Condition types of the AutoScaler, same as of the HorizontalPodAutoscaler.
+const (
+	// AbleToScale indicates whether the autoscaler is able to get and update the scale target.
+	AbleToScale = "AbleToScale"
+	// ScalingActive indicates whether the autoscaler is able to calculate the desired replica count.
+	ScalingActive = "ScalingActive"
+	// ScalingLimited indicates whether the desired replica count was capped by the replica range or scaling policies.
+	ScalingLimited = "ScalingLimited"
+)
+
 // AutoScalerStatus defines the observed state of AutoScaler.
-type AutoScalerStatus struct{}
+type AutoScalerStatus struct {
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// CurrentMetricValue is the value of the metric with the highest replica recommendation.
+	CurrentMetricValue *resource.Quantity `json:"currentMetricValue,omitempty"`
+	CurrentMetrics     []MetricStatus     `json:"currentMetrics,omitempty"`
+	LastScaleTime      *metav1.Time       `json:"lastScaleTime,omitempty"`
+	LastQueryError     string             `json:"lastQueryError,omitempty"`
+	// Predictions are the forecasts of the metrics made during the last reconciliation.
+	Predictions []MetricPrediction `json:"predictions,omitempty"`
+	// ActiveSchedules are the names of the schedules applied during the last reconciliation.
+	ActiveSchedules []string `json:"activeSchedules,omitempty"`
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Recommendations is the history of desired replica counts used for stabilization.
+	Recommendations []Recommendation `json:"recommendations,omitempty"`
+	// ScaleEvents is the history of applied replica changes used for scaling policies.
+	ScaleEvents []ScaleEvent `json:"scaleEvents,omitempty"`
+}
+
+// MetricStatus describes the last observed value of a single metric and the replica count it recommends.
+type MetricStatus struct {
+	Name            MetricName         `json:"name"`
+	CurrentValue    *resource.Quantity `json:"currentValue,omitempty"`
+	DesiredReplicas int32              `json:"desiredReplicas,omitempty"`
+	Error           string             `json:"error,omitempty"`
+}
+
+// MetricPrediction describes the forecast of a single metric and the inputs it was made from.
+type MetricPrediction struct {
+	Name MetricName `json:"name"`
+	// Time is the time the forecast is made for.
+	Time          metav1.Time        `json:"time,omitempty"`
+	ForecastValue *resource.Quantity `json:"forecastValue,omitempty"`
+	// TrendValue is the linear trend of the lookback window extrapolated to Time.
+	TrendValue *resource.Quantity `json:"trendValue,omitempty"`
+	// SeasonalStartValue and SeasonalEndValue are the values one week before now and before Time.
+	SeasonalStartValue *resource.Quantity `json:"seasonalStartValue,omitempty"`
+	SeasonalEndValue   *resource.Quantity `json:"seasonalEndValue,omitempty"`
+	Error              string             `json:"error,omitempty"`
+}
+
+type Recommendation struct {
+	Timestamp metav1.Time `json:"timestamp"`
+	Replicas  int32       `json:"replicas"`
+}
+
+type ScaleEvent struct {
+	Timestamp     metav1.Time `json:"timestamp"`
+	ReplicaChange int32       `json:"replicaChange"`
+}
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Target",type=string,JSONPath=".spec.scaleTargetRef.name"
+//+kubebuilder:printcolumn:name="Metric",type=string,JSONPath=".status.currentMetricValue"
+//+kubebuilder:printcolumn:name="MinPods",type=integer,JSONPath=".spec.minReplicas"
+//+kubebuilder:printcolumn:name="MaxPods",type=integer,JSONPath=".spec.maxReplicas"
+//+kubebuilder:printcolumn:name="Current",type=integer,JSONPath=".status.currentReplicas"
+//+kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=".status.desiredReplicas"
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // AutoScaler is the Schema for the autoScalers API.
 type AutoScaler struct {
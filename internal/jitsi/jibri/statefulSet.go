@@ -11,6 +11,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -18,7 +19,10 @@ const appName = "jibri"
 
 func (j *Jibri) Create() error {
 	preparedSTS := j.prepareSTS()
-	return j.Client.Create(j.ctx, preparedSTS)
+	if err := j.Client.Create(j.ctx, preparedSTS); err != nil {
+		return err
+	}
+	return j.UpdateStatus()
 }
 
 func (j *Jibri) prepareSTS() *appsv1.StatefulSet {
@@ -177,7 +181,16 @@ func (j *Jibri) Update(sts *appsv1.StatefulSet) error {
 	sts.Annotations = j.Annotations
 	sts.Labels = j.labels
 	sts.Spec = j.prepareSTSSpec()
-	return j.Client.Update(j.ctx, sts)
+	if err := j.Client.Update(j.ctx, sts); err != nil {
+		return err
+	}
+	return j.UpdateStatus()
+}
+
+func (j *Jibri) UpdateStatus() error {
+	j.Jibri.Status.Replicas = j.Spec.Replicas
+	j.Jibri.Status.Selector = labels.SelectorFromSet(j.labels).String()
+	return j.Client.Status().Update(j.ctx, j.Jibri)
 }
 
 func (j *Jibri) Delete() error {
@@ -41,7 +41,10 @@ func (j *Jicofo) Create() error {
 		j.log.Info("can't create jicofo logging config map", "error", err)
 	}
 	preparedDeployment := j.prepareDeployment()
-	return j.Client.Create(j.ctx, preparedDeployment)
+	if err := j.Client.Create(j.ctx, preparedDeployment); err != nil {
+		return err
+	}
+	return j.UpdateStatus()
 }
 
 func (j *Jicofo) createCustomLoggingCM() error {
@@ -205,7 +208,10 @@ func (j *Jicofo) Update() error {
 		}
 	}
 	updatedDeployment := j.prepareDeployment()
-	return j.Client.Update(j.ctx, updatedDeployment)
+	if err := j.Client.Update(j.ctx, updatedDeployment); err != nil {
+		return err
+	}
+	return j.UpdateStatus()
 }
 
 func (j *Jicofo) updateCustomLoggingCM() error {
@@ -213,7 +219,11 @@ func (j *Jicofo) updateCustomLoggingCM() error {
 	return j.Client.Update(j.ctx, logging)
 }
 
-func (j *Jicofo) UpdateStatus() error { return nil }
+func (j *Jicofo) UpdateStatus() error {
+	j.Jicofo.Status.Replicas = j.Spec.Replicas
+	j.Jicofo.Status.Selector = labels.SelectorFromSet(j.labels).String()
+	return j.Client.Status().Update(j.ctx, j.Jicofo)
+}
 
 func (j *Jicofo) Delete() error {
 	if err := utils.RemoveFinalizer(j.ctx, j.Client, j.Jicofo); err != nil {
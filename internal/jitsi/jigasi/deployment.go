@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -17,7 +18,10 @@ const name = "jigasi"
 
 func (j *Jigasi) Create() error {
 	preparedDeployment := j.prepareDeployment()
-	return j.Client.Create(j.ctx, preparedDeployment)
+	if err := j.Client.Create(j.ctx, preparedDeployment); err != nil {
+		return err
+	}
+	return j.UpdateStatus()
 }
 
 func (j *Jigasi) prepareDeployment() *appsv1.Deployment {
@@ -66,7 +70,16 @@ func (j *Jigasi) Update(deployment *appsv1.Deployment) error {
 	deployment.Annotations = j.Annotations
 	deployment.Labels = j.Labels
 	deployment.Spec = j.prepareDeploymentSpec()
-	return j.Client.Update(j.ctx, deployment)
+	if err := j.Client.Update(j.ctx, deployment); err != nil {
+		return err
+	}
+	return j.UpdateStatus()
+}
+
+func (j *Jigasi) UpdateStatus() error {
+	j.Jigasi.Status.Replicas = j.Spec.Replicas
+	j.Jigasi.Status.Selector = labels.SelectorFromSet(j.labels).String()
+	return j.Client.Status().Update(j.ctx, j.Jigasi)
 }
 
 func (j *Jigasi) Delete() error {
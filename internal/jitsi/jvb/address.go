@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jvb
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	addressPollInterval  = 15 * time.Second
+	addressLookupTimeout = 5 * time.Second
+)
+
+// needsAddress reports whether the bridges announce the address of their LoadBalancer Service.
+func (j *JVB) needsAddress() bool {
+	return j.Spec.ServiceType == v1.ServiceTypeLoadBalancer && !j.isHostNetworking() && !isHostAddressExist(j.envs)
+}
+
+// discoverAddress records the address of the Service of the selected bridge and reports whether it is known.
+// The bridge must not be started before, the reconciliation is repeated until the LoadBalancer got an ingress.
+func (j *JVB) discoverAddress() bool {
+	if !j.needsAddress() {
+		return true
+	}
+	svc, err := j.getService()
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			j.log.Info("can't get svc by replica", "error", err)
+		}
+	} else if addresses := j.serviceAddresses(svc); len(addresses) != 0 {
+		j.setAddress(j.replicaName, addresses)
+	}
+	address := j.addressOf(j.replicaName)
+	if address.IP != "" && (address.SecondaryIP != "" || !j.requiresDualStack()) {
+		return true
+	}
+	j.log.Info("waiting for the external address of the bridge", "bridge", j.replicaName)
+	j.waitsForAddress = true
+	return false
+}
+
+// serviceAddresses returns an address per IP family of the LoadBalancer Service, the address of the primary
+// family of the Service first. It is empty until the Service got an ingress.
+func (j *JVB) serviceAddresses(svc *v1.Service) []string {
+	if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	ips := make([]string, 0, len(svc.Status.LoadBalancer.Ingress)+1)
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		switch {
+		case ingress.IP != "":
+			ips = append(ips, ingress.IP)
+		case ingress.Hostname != "":
+			ips = append(ips, j.lookupHostname(ingress.Hostname)...)
+		}
+	}
+	if len(ips) == 0 && svc.Spec.LoadBalancerIP != "" {
+		ips = append(ips, svc.Spec.LoadBalancerIP)
+	}
+	return addressPerFamily(ips, svc.Spec.IPFamilies)
+}
+
+// lookupHostname resolves the hostname of an ingress without IP, e.g. of an AWS ELB.
+func (j *JVB) lookupHostname(hostname string) []string {
+	ctx, cancel := context.WithTimeout(j.ctx, addressLookupTimeout)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, hostname)
+	if err != nil {
+		j.log.Info("can't resolve the ingress hostname of the bridge service", "hostname", hostname, "error", err)
+		return nil
+	}
+	return addresses
+}
+
+// addressPerFamily returns the first address of every IP family, the address of the first of the families first.
+func addressPerFamily(ips []string, families []v1.IPFamily) []string {
+	var addresses []string
+	seen := map[v1.IPFamily]bool{}
+	for _, ip := range ips {
+		family := ipFamilyOf(ip)
+		if family == "" || seen[family] {
+			continue
+		}
+		seen[family] = true
+		if len(families) != 0 && family == families[0] {
+			addresses = append([]string{ip}, addresses...)
+			continue
+		}
+		addresses = append(addresses, ip)
+	}
+	return addresses
+}
+
+func ipFamilyOf(ip string) v1.IPFamily {
+	addr, err := netip.ParseAddr(ip)
+	switch {
+	case err != nil:
+		return ""
+	case addr.Unmap().Is4():
+		return v1.IPv4Protocol
+	default:
+		return v1.IPv6Protocol
+	}
+}
+
+// isDualStack reports whether the bridges announce an address per IP family.
+func (j *JVB) isDualStack() bool {
+	if j.Spec.IPFamilyPolicy != nil && *j.Spec.IPFamilyPolicy != v1.IPFamilyPolicySingleStack {
+		return true
+	}
+	return len(j.Spec.IPFamilies) > 1
+}
+
+func (j *JVB) requiresDualStack() bool {
+	return j.Spec.IPFamilyPolicy != nil && *j.Spec.IPFamilyPolicy == v1.IPFamilyPolicyRequireDualStack
+}
+
+// addressEnvironments returns the public addresses of the selected bridge, the address of every IP family
+// is set for dual-stack bridges.
+func (j *JVB) addressEnvironments() []v1.EnvVar {
+	address := j.addressOf(j.replicaName)
+	envs := []v1.EnvVar{{Name: "DOCKER_HOST_ADDRESS", Value: address.IP}}
+	if !j.isDualStack() || address.SecondaryIP == "" {
+		return envs
+	}
+	for _, ip := range []string{address.IP, address.SecondaryIP} {
+		envs = append(envs, v1.EnvVar{Name: "DOCKER_HOST_ADDRESS_" + strings.ToUpper(string(ipFamilyOf(ip))), Value: ip})
+	}
+	return append(envs, v1.EnvVar{Name: "JVB_ADVERTISE_IPS", Value: address.IP + "," + address.SecondaryIP})
+}
+
+func (j *JVB) addressOf(name string) v1beta1.BridgeAddress {
+	for _, address := range j.JVB.Status.Addresses {
+		if address.Name == name {
+			return address
+		}
+	}
+	return v1beta1.BridgeAddress{Name: name}
+}
+
+func (j *JVB) setAddress(name string, ips []string) {
+	address := v1beta1.BridgeAddress{Name: name, IP: ips[0]}
+	if len(ips) > 1 {
+		address.SecondaryIP = ips[1]
+	}
+	for i := range j.JVB.Status.Addresses {
+		if j.JVB.Status.Addresses[i].Name == name {
+			j.JVB.Status.Addresses[i] = address
+			return
+		}
+	}
+	j.JVB.Status.Addresses = append(j.JVB.Status.Addresses, address)
+}
+
+func (j *JVB) forgetAddress(name string) {
+	addresses := make([]v1beta1.BridgeAddress, 0, len(j.JVB.Status.Addresses))
+	for _, address := range j.JVB.Status.Addresses {
+		if address.Name != name {
+			addresses = append(addresses, address)
+		}
+	}
+	j.JVB.Status.Addresses = addresses
+}
@@ -5,6 +5,8 @@ package jvb
 
 type SIP struct {
 	Options []string
+	// DualStack maps every pod address to the public address of its IP family by an ice4j static mapping.
+	DualStack bool
 }
 
 type TurnConfig struct {
@@ -163,10 +165,29 @@ const jvbGracefulShutdown = `
 `
 
 const jvbCustomSIP = `{{"{{ if .Env.DOCKER_HOST_ADDRESS }}"}}
+{{- if .DualStack }}
+{{"{{ if and .Env.POD_IPS .Env.DOCKER_HOST_ADDRESS_IPV4 .Env.DOCKER_HOST_ADDRESS_IPV6 }}"}}
+{{"{{ range $ip := splitList \",\" .Env.POD_IPS }}"}}
+{{"{{ if contains \":\" $ip }}"}}
+ice4j.harvest.mapping.static-mappings.1.name=ipv6
+ice4j.harvest.mapping.static-mappings.1.local-address={{"{{ $ip }}"}}
+ice4j.harvest.mapping.static-mappings.1.public-address={{"{{ $.Env.DOCKER_HOST_ADDRESS_IPV6 }}"}}
+{{"{{ else }}"}}
+ice4j.harvest.mapping.static-mappings.0.name=ipv4
+ice4j.harvest.mapping.static-mappings.0.local-address={{"{{ $ip }}"}}
+ice4j.harvest.mapping.static-mappings.0.public-address={{"{{ $.Env.DOCKER_HOST_ADDRESS_IPV4 }}"}}
+{{"{{ end }}"}}
+{{"{{ end }}"}}
+{{"{{ else }}"}}
 org.ice4j.ice.harvest.NAT_HARVESTER_LOCAL_ADDRESS={{"{{ .Env.LOCAL_ADDRESS }}"}}
 org.ice4j.ice.harvest.NAT_HARVESTER_PUBLIC_ADDRESS={{"{{ .Env.DOCKER_HOST_ADDRESS }}"}}
-org.jitsi.videobridge.ENABLE_REST_SHUTDOWN=true
 {{"{{ end }}"}}
+{{- else }}
+org.ice4j.ice.harvest.NAT_HARVESTER_LOCAL_ADDRESS={{"{{ .Env.LOCAL_ADDRESS }}"}}
+org.ice4j.ice.harvest.NAT_HARVESTER_PUBLIC_ADDRESS={{"{{ .Env.DOCKER_HOST_ADDRESS }}"}}
+{{- end }}
+{{"{{ end }}"}}
+org.jitsi.videobridge.ENABLE_REST_SHUTDOWN=true
 {{ range $s := .Options }}{{ printf "%s\n" $s }} {{ end }}`
 
 const jvbCustomLogging = `handlers= java.util.logging.ConsoleHandler
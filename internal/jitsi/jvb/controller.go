@@ -11,11 +11,14 @@ import (
 	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
 	meeterr "github.com/onmetal/meeting-operator/internal/errors"
 	"github.com/onmetal/meeting-operator/internal/utils"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
@@ -35,12 +38,19 @@ type JVB struct {
 	envs          []corev1.EnvVar
 	replicaName   string
 	replica, port int32
+	// waitsForAddress is set if a bridge isn't started yet, as its LoadBalancer has no ingress.
+	waitsForAddress bool
 }
 
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1beta1.JVB{}).
-		WithEventFilter(r.constructPredicates()).
+		For(&v1beta1.JVB{}, builder.WithPredicates(r.constructPredicates())).
+		Owns(&corev1.Service{}, builder.WithPredicates(predicate.Funcs{UpdateFunc: isIngressUpdated})).
+		Owns(&appsv1.StatefulSet{}, builder.WithPredicates(predicate.Funcs{UpdateFunc: isReplicasUpdated})).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.hostNetworkingJVBs),
+			builder.WithPredicates(predicate.Funcs{UpdateFunc: isNodeAddressUpdated})).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.drainingJVBs),
+			builder.WithPredicates(predicate.Funcs{UpdateFunc: isBridgeExited, CreateFunc: ignoreCreate, DeleteFunc: ignoreDelete})).
 		Complete(r)
 }
 
@@ -50,6 +60,10 @@ func (r *Reconciler) constructPredicates() predicate.Predicate {
 	}
 }
 
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=jitsi.meeting.ko,resources=jvbs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=jitsi.meeting.ko,resources=jvbs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=jitsi.meeting.ko,resources=jvbs/finalizers,verbs=update
@@ -72,6 +86,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, updErr
 	}
 	reqLogger.Info("reconciliation finished")
+	if jvb.waitsForAddress {
+		return ctrl.Result{RequeueAfter: addressPollInterval}, nil
+	}
+	if jvb.hasDrainingBridges() || jvb.isRollingOut() {
+		return ctrl.Result{RequeueAfter: drainPollInterval}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -120,3 +140,97 @@ func isSpecUpdated(e event.UpdateEvent) bool {
 	}
 	return true
 }
+
+// isIngressUpdated reconciles the JVB once the LoadBalancer of a bridge Service got its ingress.
+func isIngressUpdated(e event.UpdateEvent) bool {
+	oldObj, oldOk := e.ObjectOld.(*corev1.Service)
+	newObj, newOk := e.ObjectNew.(*corev1.Service)
+	if !oldOk || !newOk {
+		return false
+	}
+	return !reflect.DeepEqual(oldObj.Status.LoadBalancer.Ingress, newObj.Status.LoadBalancer.Ingress)
+}
+
+// isReplicasUpdated reconciles the JVB once the replica count of its StatefulSet changed, which is reported
+// in the status of the JVB.
+func isReplicasUpdated(e event.UpdateEvent) bool {
+	oldObj, oldOk := e.ObjectOld.(*appsv1.StatefulSet)
+	newObj, newOk := e.ObjectNew.(*appsv1.StatefulSet)
+	if !oldOk || !newOk {
+		return false
+	}
+	return oldObj.Status.Replicas != newObj.Status.Replicas
+}
+
+// hostNetworkingJVBs enqueues the JVBs in a host network mode, so that the address of a new or changed node
+// is stored in their jvb-nodes ConfigMap before a bridge is started on it.
+func (r *Reconciler) hostNetworkingJVBs(ctx context.Context, _ client.Object) []ctrl.Request {
+	var jvbs v1beta1.JVBList
+	if err := r.List(ctx, &jvbs); err != nil {
+		r.Log.Info("can't list jvbs", "error", err)
+		return nil
+	}
+	var requests []ctrl.Request
+	for i := range jvbs.Items {
+		j := &JVB{JVB: &jvbs.Items[i]}
+		if j.isHostNetworking() {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(j.JVB)})
+		}
+	}
+	return requests
+}
+
+func isNodeAddressUpdated(e event.UpdateEvent) bool {
+	oldObj, oldOk := e.ObjectOld.(*corev1.Node)
+	newObj, newOk := e.ObjectNew.(*corev1.Node)
+	if !oldOk || !newOk {
+		return false
+	}
+	return nodeAddress(oldObj) != nodeAddress(newObj)
+}
+
+// drainingJVBs enqueues the JVBs which drain the bridge of the pod, so that the bridge is removed as soon as it
+// exited after its graceful shutdown and before its restarted container takes new conferences.
+func (r *Reconciler) drainingJVBs(ctx context.Context, obj client.Object) []ctrl.Request {
+	name := obj.GetLabels()[bridgeLabel]
+	if name == "" {
+		name = obj.GetLabels()[podNameLabel]
+	}
+	if name == "" {
+		return nil
+	}
+	var jvbs v1beta1.JVBList
+	if err := r.List(ctx, &jvbs, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Info("can't list jvbs", "error", err)
+		return nil
+	}
+	var requests []ctrl.Request
+	for i := range jvbs.Items {
+		j := &JVB{JVB: &jvbs.Items[i]}
+		if j.isDraining(name) || (j.JVB.Status.Rollout != nil && j.upgradeOf(name) != nil) {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(j.JVB)})
+		}
+	}
+	return requests
+}
+
+// isBridgeExited reconciles the JVB once the bridge container of a pod terminated or was restarted.
+func isBridgeExited(e event.UpdateEvent) bool {
+	oldObj, oldOk := e.ObjectOld.(*corev1.Pod)
+	newObj, newOk := e.ObjectNew.(*corev1.Pod)
+	if !oldOk || !newOk {
+		return false
+	}
+	oldStatus, newStatus := bridgeStatus(oldObj), bridgeStatus(newObj)
+	switch {
+	case newStatus == nil:
+		return false
+	case oldStatus == nil:
+		return newStatus.State.Terminated != nil
+	}
+	return newStatus.RestartCount > oldStatus.RestartCount || (newStatus.State.Terminated != nil && oldStatus.State.Terminated == nil)
+}
+
+func ignoreCreate(event.CreateEvent) bool { return false }
+
+func ignoreDelete(event.DeleteEvent) bool { return false }
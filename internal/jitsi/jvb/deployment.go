@@ -7,12 +7,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"html/template"
-	"time"
+	"text/template"
 
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
 	"github.com/onmetal/meeting-operator/internal/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -25,6 +26,7 @@ import (
 const (
 	appName         = "jvb"
 	colibriHTTPPort = 8080
+	podIPsEnv       = "POD_IPS"
 )
 
 const (
@@ -35,11 +37,6 @@ const (
 	failureThreshold    = 3
 )
 
-const (
-	timeOut   = 300 * time.Second
-	tickTimer = 15 * time.Second
-)
-
 const (
 	telegrafExporter            = "telegraf"
 	exporterContainerName       = "exporter"
@@ -48,18 +45,25 @@ const (
 
 func (j *JVB) Create() error {
 	j.createConfigMaps()
-	for replica := int32(1); replica <= j.Spec.Replicas; replica++ {
-		j.replicaName = fmt.Sprintf("%s-%d", appName, replica)
-		j.replica = replica
-		if j.isExist() {
-			continue
-		}
+	if j.isStatefulSet() {
+		// the StatefulSet and its per-pod resources are reconciled by Update
+		return nil
+	}
+	replicas, err := j.replicasToCreate()
+	if err != nil {
+		return err
+	}
+	for _, replica := range replicas {
+		j.setReplica(replica)
 		if err := j.servicePerInstance(); err != nil {
 			j.log.Info("failed to create service", "error", err)
 		}
 		if err := j.createCustomSIPCM(); err != nil {
 			j.log.Info("can't create custom sip config map", "error", err)
 		}
+		if !j.discoverAddress() {
+			continue
+		}
 		if err := j.createInstance(); err != nil {
 			j.log.Info("failed to create jvb", "error", err)
 		}
@@ -74,13 +78,11 @@ func (j *JVB) createConfigMaps() {
 	if err := j.createCustomLoggingCM(); err != nil && !apierrors.IsAlreadyExists(err) {
 		j.log.Info("can't create jvb logging config map", "error", err)
 	}
-}
-
-func (j *JVB) isExist() bool {
-	if _, err := j.getInstance(); err != nil && apierrors.IsNotFound(err) {
-		return false
+	if j.isHostNetworking() {
+		if err := j.updateNodesCM(); err != nil {
+			j.log.Info("can't update jvb nodes config map", "error", err)
+		}
 	}
-	return true
 }
 
 func (j *JVB) createShutdownCM() error {
@@ -119,7 +121,7 @@ func (j *JVB) prepareSIPCM() *v1.ConfigMap {
 		return nil
 	}
 	var b bytes.Buffer
-	d := SIP{Options: j.Spec.CustomSIP}
+	d := SIP{Options: j.Spec.CustomSIP, DualStack: j.isDualStack()}
 	if executeErr := tpl.Execute(&b, d); executeErr != nil {
 		j.log.Info("can't template sip config", "error", err)
 		return nil
@@ -168,13 +170,30 @@ func (j *JVB) servicePerInstance() error {
 			j.log.Info("can't create exporter service", "error", exporterErr)
 		}
 	}
+	if j.isHostNetworking() {
+		// the bridge is reached by the address of its node, the media Service of a former network mode is removed
+		if getErr != nil {
+			return client.IgnoreNotFound(getErr)
+		}
+		return client.IgnoreNotFound(j.Client.Delete(j.ctx, service))
+	}
 	switch {
 	case apierrors.IsNotFound(getErr):
 		return j.Client.Create(j.ctx, preparedService)
 	default:
 		service.ObjectMeta.Annotations = preparedService.Annotations
+		service.ObjectMeta.OwnerReferences = preparedService.OwnerReferences
 		service.Spec.Ports = preparedService.Spec.Ports
+		service.Spec.Selector = preparedService.Spec.Selector
 		service.Spec.Type = j.Spec.ServiceType
+		// the primary IP family of a Service is immutable, so the families are only set on create
+		if j.Spec.IPFamilyPolicy != nil {
+			service.Spec.IPFamilyPolicy = j.Spec.IPFamilyPolicy
+			if *j.Spec.IPFamilyPolicy == v1.IPFamilyPolicySingleStack && len(service.Spec.IPFamilies) > 1 {
+				service.Spec.IPFamilies = service.Spec.IPFamilies[:1]
+				service.Spec.ClusterIPs = service.Spec.ClusterIPs[:min(len(service.Spec.ClusterIPs), 1)]
+			}
+		}
 		return j.Client.Update(j.ctx, service)
 	}
 }
@@ -183,14 +202,17 @@ func (j *JVB) prepareServiceForInstance() *v1.Service {
 	port := j.port + j.replica
 	return &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        j.replicaName,
-			Namespace:   j.Namespace,
-			Annotations: j.Spec.ServiceAnnotations,
+			Name:            j.replicaName,
+			Namespace:       j.Namespace,
+			Annotations:     j.Spec.ServiceAnnotations,
+			OwnerReferences: j.ownerReferences(),
 		},
 		Spec: v1.ServiceSpec{
-			Type:     j.Spec.ServiceType,
-			Ports:    []v1.ServicePort{{Name: appName, Protocol: j.Spec.Port.Protocol, Port: port, TargetPort: intstr.IntOrString{IntVal: port}}},
-			Selector: map[string]string{"jitsi-jvb": j.replicaName},
+			Type:           j.Spec.ServiceType,
+			Ports:          []v1.ServicePort{{Name: appName, Protocol: j.Spec.Port.Protocol, Port: port, TargetPort: intstr.IntOrString{IntVal: port}}},
+			Selector:       j.bridgeSelector(j.replicaName),
+			IPFamilyPolicy: j.Spec.IPFamilyPolicy,
+			IPFamilies:     j.Spec.IPFamilies,
 		},
 	}
 }
@@ -204,6 +226,7 @@ func (j *JVB) serviceForExporter() *v1.Service {
 				"app":                   "jvb",
 				"kubernetes.io/part-of": "jitsi",
 			},
+			OwnerReferences: j.ownerReferences(),
 		},
 		Spec: v1.ServiceSpec{
 			Type: v1.ServiceTypeClusterIP,
@@ -211,7 +234,7 @@ func (j *JVB) serviceForExporter() *v1.Service {
 				Name: "exporter", Protocol: v1.ProtocolTCP,
 				Port: j.Spec.Exporter.Port, TargetPort: intstr.IntOrString{IntVal: j.Spec.Exporter.Port},
 			}},
-			Selector: map[string]string{"jitsi-jvb": j.replicaName},
+			Selector: j.bridgeSelector(j.replicaName),
 		},
 	}
 }
@@ -222,7 +245,7 @@ func (j *JVB) createInstance() error {
 }
 
 func (j *JVB) prepareInstance() *appsv1.Deployment {
-	l := map[string]string{"jitsi-jvb": j.replicaName}
+	l := map[string]string{bridgeLabel: j.replicaName}
 	spec := j.prepareDeploymentSpecWithLabels(l)
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -239,7 +262,7 @@ func (j *JVB) prepareDeploymentSpecWithLabels(l map[string]string) appsv1.Deploy
 	jvb := j.prepareJVBContainer()
 	exporter := j.prepareExporterContainer()
 	volumes := j.prepareVolumesForJVB()
-	return appsv1.DeploymentSpec{
+	spec := appsv1.DeploymentSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: l,
 		},
@@ -258,15 +281,21 @@ func (j *JVB) prepareDeploymentSpecWithLabels(l map[string]string) appsv1.Deploy
 			},
 		},
 	}
+	if j.isHostNetworking() {
+		// the new pod can't start on the node of the old one
+		spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+	}
+	j.applyNetworkMode(&spec.Template.Spec)
+	return spec
 }
 
 func (j *JVB) prepareJVBContainer() v1.Container {
-	port := j.port + j.replica
 	return v1.Container{
 		Name:            appName,
 		Image:           j.Spec.Image,
 		ImagePullPolicy: j.Spec.ImagePullPolicy,
-		Env:             j.additionalEnvironments(),
+		Command:         j.entrypoint(),
+		Env:             j.podEnvironments(),
 		Resources:       j.Spec.Resources,
 		SecurityContext: &j.Spec.SecurityContext,
 		VolumeMounts: []v1.VolumeMount{
@@ -295,19 +324,18 @@ func (j *JVB) prepareJVBContainer() v1.Container {
 			SuccessThreshold:    successThreshold,
 			FailureThreshold:    failureThreshold,
 		},
-		Ports: []v1.ContainerPort{
-			{
-				Name:          appName,
-				Protocol:      j.Spec.Port.Protocol,
-				ContainerPort: port,
-			},
-			{
-				Name:          "colibri",
-				Protocol:      v1.ProtocolTCP,
-				ContainerPort: colibriHTTPPort,
-			},
-		},
+		Ports: j.containerPorts(),
+	}
+}
+
+// containerPorts declares the media port of the bridge. The pods of the StatefulSet share the template but
+// listen on the port of their ordinal, so only the colibri port is declared for them.
+func (j *JVB) containerPorts() []v1.ContainerPort {
+	colibri := v1.ContainerPort{Name: "colibri", Protocol: v1.ProtocolTCP, ContainerPort: colibriHTTPPort}
+	if j.isStatefulSet() {
+		return []v1.ContainerPort{colibri}
 	}
+	return []v1.ContainerPort{{Name: appName, Protocol: j.Spec.Port.Protocol, ContainerPort: j.port + j.replica}, colibri}
 }
 
 func (j *JVB) prepareVolumesForJVB() []v1.Volume {
@@ -334,78 +362,34 @@ func (j *JVB) prepareVolumesForJVB() []v1.Volume {
 }
 
 func (j *JVB) additionalEnvironments() []v1.EnvVar {
-	port := fmt.Sprint(j.port + j.replica)
-	switch {
-	case j.Spec.Port.Protocol == v1.ProtocolTCP:
-		if isEnvAlreadyExist(j.envs) {
-			return j.envs
-		}
-		additionalEnvs := make([]v1.EnvVar, 0, 6) //nolint:mnd //reason: just minimal value
-		if !isHostAddressExist(j.envs) {
-			additionalEnvs = append(additionalEnvs, j.getDockerHostAddr())
-		}
-		additionalEnvs = append(additionalEnvs,
-			v1.EnvVar{Name: "JVB_PORT", Value: "30300"},
-			v1.EnvVar{Name: "JVB_TCP_PORT", Value: port},
-			v1.EnvVar{Name: "JVB_TCP_MAPPED_PORT", Value: port},
-			v1.EnvVar{Name: "TCP_HARVESTER_PORT", Value: port},
-			v1.EnvVar{Name: "TCP_HARVESTER_MAPPED_PORT", Value: port})
-		j.envs = append(j.envs, additionalEnvs...)
+	if j.isStatefulSet() {
+		// the environment which depends on the pod ordinal is loaded from the instances config map
 		return j.envs
-	case j.Spec.Port.Protocol == v1.ProtocolUDP:
-		if isEnvAlreadyExist(j.envs) {
-			return j.envs
-		}
-		additionalEnvs := make([]v1.EnvVar, 0, 2) //nolint:mnd //reason: just minimal value
-		if !isHostAddressExist(j.envs) {
-			additionalEnvs = append(additionalEnvs, j.getDockerHostAddr())
-		}
-		additionalEnvs = append(additionalEnvs,
-			v1.EnvVar{Name: "JVB_PORT", Value: port})
-		j.envs = append(j.envs, additionalEnvs...)
-		return j.envs
-	default:
+	}
+	if isEnvAlreadyExist(j.envs) {
 		return j.envs
 	}
+	envs := make([]v1.EnvVar, 0, len(j.envs)+6) //nolint:mnd //reason: just minimal value
+	envs = append(envs, j.envs...)
+	return append(envs, j.instanceEnvironments()...)
 }
 
-func (j *JVB) getDockerHostAddr() v1.EnvVar {
-	if j.Spec.ServiceType != v1.ServiceTypeLoadBalancer {
-		return v1.EnvVar{
-			Name:  "DOCKER_HOST_ADDRESS",
-			Value: "",
-		}
-	}
-	return v1.EnvVar{
-		Name:  "DOCKER_HOST_ADDRESS",
-		Value: j.getExternalIP(),
+// podEnvironments adds the addresses of the pod to the environment of dual-stack bridges, they are mapped to the
+// public address of their IP family.
+func (j *JVB) podEnvironments() []v1.EnvVar {
+	envs := j.additionalEnvironments()
+	if !j.isDualStack() {
+		return envs
 	}
+	return append(envs[:len(envs):len(envs)], v1.EnvVar{
+		Name:      podIPsEnv,
+		ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIPs"}},
+	})
 }
 
-func (j *JVB) getExternalIP() string {
-	timeout := time.After(timeOut)
-	tick := time.NewTicker(tickTimer)
-	for {
-		select {
-		case <-timeout:
-			return ""
-		case <-tick.C:
-			svc, err := j.getService()
-			if apierrors.IsNotFound(err) {
-				j.log.Info("can't get svc by replica", "error", err)
-				return ""
-			}
-			if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
-				return ""
-			}
-			if len(svc.Status.LoadBalancer.Ingress) != 0 {
-				return svc.Status.LoadBalancer.Ingress[0].IP
-			}
-			if svc.Spec.LoadBalancerIP != "" {
-				return svc.Spec.LoadBalancerIP
-			}
-		}
-	}
+// ownerReferences makes the JVB the owner of the bridge resources, its controller watches them.
+func (j *JVB) ownerReferences() []metav1.OwnerReference {
+	return []metav1.OwnerReference{*metav1.NewControllerRef(j.JVB, v1beta1.GroupVersion.WithKind("JVB"))}
 }
 
 func (j *JVB) getService() (*v1.Service, error) {
@@ -451,11 +435,24 @@ func (j *JVB) prepareExporterContainer() v1.Container {
 }
 
 func (j *JVB) Update() error {
-	j.updateReplicaCount()
+	if j.isStatefulSet() {
+		return j.updateStatefulSet()
+	}
+	j.drain()
 	j.updateOrRecreateConfigMaps()
-	for replica := int32(1); replica <= j.Spec.Replicas; replica++ {
-		j.replicaName = fmt.Sprintf("%s-%d", appName, replica)
-		j.replica = replica
+	replicas, err := j.activeBridges()
+	if err != nil {
+		return err
+	}
+	j.startRollout()
+	names := make([]string, 0, len(replicas))
+	for _, replica := range replicas {
+		names = append(names, bridgeName(replica))
+	}
+	j.forgetUpgrades(names)
+	var outdated []string
+	for _, replica := range replicas {
+		j.setReplica(replica)
 		if err := j.updateCustomSIPCM(); err != nil {
 			if apierrors.IsNotFound(err) {
 				if createErr := j.createCustomSIPCM(); createErr != nil && !apierrors.IsAlreadyExists(createErr) {
@@ -473,31 +470,24 @@ func (j *JVB) Update() error {
 		if svcCreationErr := j.servicePerInstance(); svcCreationErr != nil {
 			j.log.Info("failed to create service", "error", svcCreationErr)
 		}
+		if !j.discoverAddress() {
+			continue
+		}
 		prepared := j.prepareDeploymentSpecWithLabels(nil)
+		if !j.upgrade(j.replicaName, !equality.Semantic.DeepDerivative(prepared.Template, instance.Spec.Template)) {
+			outdated = append(outdated, j.replicaName)
+			continue
+		}
+		instance.Spec.Strategy = prepared.Strategy
 		instance.Spec.Template.Spec = prepared.Template.Spec
 		if err := j.Client.Update(j.ctx, instance); err != nil {
 			j.log.Info("can't update jvb instance", "error", err)
 		}
 	}
+	j.continueRollout(j.byConferences(outdated))
 	return j.UpdateStatus()
 }
 
-func (j *JVB) updateReplicaCount() {
-	if j.JVB.Status.Replicas > j.Spec.Replicas {
-		currentReplicaCount := j.JVB.Status.Replicas
-		for currentReplicaCount != j.Spec.Replicas && currentReplicaCount != 1 {
-			j.replicaName = fmt.Sprintf("%s-%d", appName, currentReplicaCount)
-			if err := j.deleteService(); client.IgnoreNotFound(err) != nil {
-				j.log.Info("failed to delete service", "error", err)
-			}
-			if err := j.deleteInstance(); client.IgnoreNotFound(err) != nil {
-				j.log.Info("failed to delete instance", "error", err)
-			}
-			currentReplicaCount--
-		}
-	}
-}
-
 func (j *JVB) updateOrRecreateConfigMaps() {
 	if err := j.updateShutdownCM(); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -540,8 +530,34 @@ func (j *JVB) getInstance() (*appsv1.Deployment, error) {
 	return d, err
 }
 
+// bridgesSelector selects the pods of all bridges, the pods of every bridge carry the bridge label with its name.
+// The pod template labels of the existing bridges are kept, so that they aren't restarted by an operator upgrade.
+func bridgesSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: bridgeLabel, Operator: metav1.LabelSelectorOpExists}},
+	}
+}
+
+// UpdateStatus reports the replicas and selector of the scale subresource. In StatefulSet mode they are
+// taken from the StatefulSet, which may still keep draining pods.
 func (j *JVB) UpdateStatus() error {
 	j.JVB.Status.Replicas = j.Spec.Replicas
+	labelSelector := bridgesSelector()
+	if j.isStatefulSet() {
+		sts, err := j.getStatefulSet()
+		switch {
+		case err == nil:
+			j.JVB.Status.Replicas = sts.Status.Replicas
+			labelSelector = sts.Spec.Selector
+		case !apierrors.IsNotFound(err):
+			j.log.Info("failed to get jvb statefulset", "error", err)
+		}
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return err
+	}
+	j.JVB.Status.Selector = selector.String()
 	return j.Client.Status().Update(j.ctx, j.JVB)
 }
 
@@ -549,8 +565,15 @@ func (j *JVB) Delete() error {
 	if err := utils.RemoveFinalizer(j.ctx, j.Client, j.JVB); err != nil {
 		j.log.Info("can't remove finalizer", "error", err)
 	}
-	for replica := int32(1); replica <= j.Spec.Replicas; replica++ {
-		j.replicaName = fmt.Sprintf("%s-%d", appName, replica)
+	if j.isStatefulSet() {
+		return j.deleteStatefulSet()
+	}
+	replicas, err := j.listBridges()
+	if err != nil {
+		return err
+	}
+	for _, replica := range replicas {
+		j.setReplica(replica)
 		if err := j.deleteService(); client.IgnoreNotFound(err) != nil {
 			j.log.Info("failed to delete service", "error", err)
 		}
@@ -578,6 +601,7 @@ func (j *JVB) deleteInstance() error {
 }
 
 func (j *JVB) deleteService() error {
+	j.forgetAddress(j.replicaName)
 	svc, err := j.getService()
 	if err != nil {
 		if apierrors.IsNotFound(err) {
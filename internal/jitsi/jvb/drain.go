@@ -0,0 +1,344 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jvb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	bridgeLabel           = "jitsi-jvb"
+	defaultDrainTimeout   = time.Hour
+	drainPollInterval     = 30 * time.Second
+	colibriRequestTimeout = 5 * time.Second
+)
+
+var (
+	errBridgeNotRunning   = errors.New("bridge pod is not running")
+	errBridgeUnreachable  = errors.New("bridge is unreachable")
+	errUnexpectedResponse = errors.New("unexpected response from bridge")
+)
+
+var colibriClient = &http.Client{Timeout: colibriRequestTimeout}
+
+type colibriStats struct {
+	Conferences int32 `json:"conferences"`
+}
+
+func bridgeName(replica int32) string {
+	return fmt.Sprintf("%s-%d", appName, replica)
+}
+
+func bridgeReplica(name string) (int32, bool) {
+	replica, err := strconv.ParseInt(strings.TrimPrefix(name, appName+"-"), 10, 32)
+	if err != nil || replica < 1 {
+		return 0, false
+	}
+	return int32(replica), true
+}
+
+func (j *JVB) setReplica(replica int32) {
+	j.replica = replica
+	j.replicaName = bridgeName(replica)
+}
+
+// listBridges returns the replica numbers of all bridge deployments, including the draining ones.
+func (j *JVB) listBridges() ([]int32, error) {
+	var deployments appsv1.DeploymentList
+	if err := j.Client.List(j.ctx, &deployments, client.InNamespace(j.Namespace), client.HasLabels{bridgeLabel}); err != nil {
+		return nil, err
+	}
+	replicas := make([]int32, 0, len(deployments.Items))
+	for i := range deployments.Items {
+		if replica, ok := bridgeReplica(deployments.Items[i].Name); ok {
+			replicas = append(replicas, replica)
+		}
+	}
+	sort.Slice(replicas, func(a, b int) bool { return replicas[a] < replicas[b] })
+	return replicas, nil
+}
+
+// activeBridges returns the replica numbers of the bridges which are not draining.
+func (j *JVB) activeBridges() ([]int32, error) {
+	replicas, err := j.listBridges()
+	if err != nil {
+		return nil, err
+	}
+	active := make([]int32, 0, len(replicas))
+	for _, replica := range replicas {
+		if !j.isDraining(bridgeName(replica)) {
+			active = append(active, replica)
+		}
+	}
+	return active, nil
+}
+
+// replicasToCreate returns the lowest free replica numbers needed to reach the desired bridge count.
+func (j *JVB) replicasToCreate() ([]int32, error) {
+	existing, err := j.listBridges()
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[int32]bool, len(existing))
+	var active int32
+	for _, replica := range existing {
+		taken[replica] = true
+		if !j.isDraining(bridgeName(replica)) {
+			active++
+		}
+	}
+	var missing []int32
+	for replica := int32(1); active+int32(len(missing)) < j.Spec.Replicas; replica++ {
+		if !taken[replica] {
+			missing = append(missing, replica)
+		}
+	}
+	return missing, nil
+}
+
+func (j *JVB) isDraining(name string) bool {
+	for i := range j.JVB.Status.Draining {
+		if j.JVB.Status.Draining[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *JVB) hasDrainingBridges() bool {
+	return len(j.JVB.Status.Draining) != 0
+}
+
+// drain puts the surplus bridges with the fewest conferences into graceful shutdown and deletes
+// the draining bridges once they host no conferences or the drain timeout passed.
+func (j *JVB) drain() {
+	active, err := j.activeBridges()
+	if err != nil {
+		j.log.Info("can't list jvb instances", "error", err)
+		return
+	}
+	if surplus := len(active) - int(max(j.Spec.Replicas, 1)); surplus > 0 {
+		j.startDrain(active, surplus)
+	}
+	draining := make([]v1beta1.BridgeDrain, 0, len(j.JVB.Status.Draining))
+	for _, bridge := range j.JVB.Status.Draining {
+		if !j.isDrained(&bridge) {
+			draining = append(draining, bridge)
+			continue
+		}
+		j.replicaName = bridge.Name
+		if err := j.deleteService(); client.IgnoreNotFound(err) != nil {
+			j.log.Info("failed to delete service", "error", err)
+		}
+		if err := j.deleteInstance(); client.IgnoreNotFound(err) != nil {
+			j.log.Info("failed to delete instance", "error", err)
+			draining = append(draining, bridge)
+		}
+	}
+	j.JVB.Status.Draining = draining
+}
+
+func (j *JVB) startDrain(active []int32, surplus int) {
+	conferences := make(map[int32]int32, len(active))
+	for _, replica := range active {
+		count, err := j.conferences(bridgeName(replica))
+		if err != nil {
+			j.log.Info("can't get bridge conferences", "bridge", bridgeName(replica), "error", err)
+		}
+		conferences[replica] = count
+	}
+	candidates := append([]int32(nil), active...)
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if conferences[candidates[a]] != conferences[candidates[b]] {
+			return conferences[candidates[a]] < conferences[candidates[b]]
+		}
+		return candidates[a] > candidates[b]
+	})
+	for _, replica := range candidates[:surplus] {
+		j.JVB.Status.Draining = append(j.JVB.Status.Draining, j.shutdownBridge(bridgeName(replica), conferences[replica]))
+	}
+}
+
+// shutdownBridge puts the bridge into graceful shutdown and returns its drain.
+func (j *JVB) shutdownBridge(name string, conferences int32) v1beta1.BridgeDrain {
+	bridge := v1beta1.BridgeDrain{Name: name, StartTime: metav1.Now(), Conferences: conferences}
+	if err := j.gracefulShutdown(name); err != nil {
+		j.log.Info("can't start graceful shutdown of bridge", "bridge", name, "error", err)
+		return bridge
+	}
+	bridge.ShutdownRequested = true
+	return bridge
+}
+
+// isDrained updates the conference count of the bridge and reports whether it can be deleted.
+// After the graceful shutdown the bridge exits and its container is restarted, so the bridge counts as drained
+// once it exited or isn't reachable anymore, before the restarted bridge takes new conferences.
+func (j *JVB) isDrained(bridge *v1beta1.BridgeDrain) bool {
+	pod, err := j.bridgePod(bridge.Name)
+	if errors.Is(err, errBridgeNotRunning) {
+		return true
+	}
+	if err == nil && bridge.ShutdownRequested && hasExited(pod, bridge.StartTime.Time) {
+		return true
+	}
+	count, err := j.conferences(bridge.Name)
+	switch {
+	case errors.Is(err, errBridgeNotRunning):
+		return true
+	case bridge.ShutdownRequested && (errors.Is(err, errBridgeUnreachable) || errors.Is(err, errUnexpectedResponse)):
+		return true
+	case err != nil:
+		j.log.Info("can't get bridge conferences", "bridge", bridge.Name, "error", err)
+	default:
+		bridge.Conferences = count
+		if count == 0 {
+			return true
+		}
+	}
+	if !bridge.ShutdownRequested {
+		// the bridge wasn't reachable when the drain started
+		if err := j.gracefulShutdown(bridge.Name); err != nil {
+			j.log.Info("can't start graceful shutdown of bridge", "bridge", bridge.Name, "error", err)
+		} else {
+			bridge.ShutdownRequested = true
+		}
+	}
+	if time.Since(bridge.StartTime.Time) > j.drainTimeout() {
+		j.log.Info("drain timeout passed, deleting bridge", "bridge", bridge.Name, "conferences", bridge.Conferences)
+		return true
+	}
+	return false
+}
+
+func (j *JVB) drainTimeout() time.Duration {
+	if j.Spec.DrainTimeout == nil {
+		return defaultDrainTimeout
+	}
+	return j.Spec.DrainTimeout.Duration
+}
+
+func (j *JVB) conferences(name string) (int32, error) {
+	url, err := j.colibriURL(name, "/colibri/stats")
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(j.ctx, colibriRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := colibriClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errBridgeUnreachable, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			j.log.Info("can't close colibri response body", "error", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: %s", errUnexpectedResponse, resp.Status)
+	}
+	stats := colibriStats{}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return 0, err
+	}
+	return stats.Conferences, nil
+}
+
+// gracefulShutdown makes the bridge stop accepting new conferences, it exits once the running ones ended.
+func (j *JVB) gracefulShutdown(name string) error {
+	url, err := j.colibriURL(name, "/colibri/shutdown")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(j.ctx, colibriRequestTimeout)
+	defer cancel()
+	body := bytes.NewBufferString(`{"graceful-shutdown": "true"}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := colibriClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			j.log.Info("can't close colibri response body", "error", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", errUnexpectedResponse, resp.Status)
+	}
+	return nil
+}
+
+// colibriURL returns the url of the colibri REST API of the running bridge pod.
+func (j *JVB) colibriURL(name, path string) (string, error) {
+	pod, err := j.bridgePod(name)
+	if err != nil {
+		return "", err
+	}
+	return podURL(pod, path), nil
+}
+
+// bridgePod returns the running pod of the bridge.
+func (j *JVB) bridgePod(name string) (*v1.Pod, error) {
+	var pods v1.PodList
+	if err := j.Client.List(j.ctx, &pods, client.InNamespace(j.Namespace), client.MatchingLabels(j.bridgeSelector(name))); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == v1.PodRunning && pod.Status.PodIP != "" && pod.DeletionTimestamp.IsZero() {
+			return pod, nil
+		}
+	}
+	return nil, errBridgeNotRunning
+}
+
+// hasExited reports whether the bridge container of the pod terminated since the time.
+func hasExited(pod *v1.Pod, since time.Time) bool {
+	status := bridgeStatus(pod)
+	if status == nil {
+		return false
+	}
+	if status.State.Terminated != nil {
+		return true
+	}
+	last := status.LastTerminationState.Terminated
+	return last != nil && last.FinishedAt.After(since)
+}
+
+func bridgeStatus(pod *v1.Pod) *v1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == appName {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+func podURL(pod *v1.Pod, path string) string {
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(colibriHTTPPort)), path)
+}
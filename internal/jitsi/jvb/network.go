@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jvb
+
+import (
+	"strings"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	nodesCMName   = "jvb-nodes"
+	nodesVolume   = "nodes"
+	hostnameLabel = "kubernetes.io/hostname"
+	nodeNameEnv   = "NODE_NAME"
+)
+
+// isHostNetworking reports whether the bridges are reached by the address of their node instead of a Service.
+func (j *JVB) isHostNetworking() bool {
+	return j.Spec.NetworkMode == v1beta1.NetworkModeHostNetwork || j.Spec.NetworkMode == v1beta1.NetworkModeHostPort
+}
+
+// entrypoint returns the command of the bridge container, which loads the environment known only after
+// the pod was created before the bridge is started.
+func (j *JVB) entrypoint() []string {
+	var steps []string
+	if j.isStatefulSet() {
+		steps = append(steps, `set -a && . "/instances/$(hostname)" && set +a`)
+	}
+	if j.isHostNetworking() && !isHostAddressExist(j.envs) {
+		// the address of a new node is known once the ConfigMap volume was synced
+		steps = append(steps, `until [ -s "/nodes/${NODE_NAME}" ]; do sleep 1; done`,
+			`DOCKER_HOST_ADDRESS="$(cat "/nodes/${NODE_NAME}")" && export DOCKER_HOST_ADDRESS`)
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	return []string{"/bin/bash", "-c", strings.Join(append(steps, "exec /init"), " && ")}
+}
+
+// applyNetworkMode runs the bridge pods on the network of their nodes, one bridge per node.
+func (j *JVB) applyNetworkMode(spec *v1.PodSpec) {
+	if !j.isHostNetworking() {
+		return
+	}
+	if j.Spec.NetworkMode == v1beta1.NetworkModeHostNetwork {
+		spec.HostNetwork = true
+		spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
+	}
+	spec.Affinity = &v1.Affinity{
+		PodAntiAffinity: &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+				LabelSelector: bridgesSelector(),
+				TopologyKey:   hostnameLabel,
+			}},
+		},
+	}
+	spec.Volumes = append(spec.Volumes, v1.Volume{
+		Name: nodesVolume, VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+			LocalObjectReference: v1.LocalObjectReference{Name: nodesCMName},
+		}},
+	})
+	jvb := &spec.Containers[0]
+	jvb.Env = append(jvb.Env[:len(jvb.Env):len(jvb.Env)], v1.EnvVar{
+		Name:      nodeNameEnv,
+		ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "spec.nodeName"}},
+	})
+	jvb.VolumeMounts = append(jvb.VolumeMounts, v1.VolumeMount{Name: nodesVolume, MountPath: "/nodes"})
+	for i := range jvb.Ports {
+		if jvb.Ports[i].Name == appName {
+			jvb.Ports[i].HostPort = jvb.Ports[i].ContainerPort
+		}
+	}
+}
+
+// updateNodesCM stores the public address of every node, the bridge reads the address of its node on start.
+func (j *JVB) updateNodesCM() error {
+	var nodes v1.NodeList
+	if err := j.Client.List(j.ctx, &nodes); err != nil {
+		return err
+	}
+	addresses := make(map[string]string, len(nodes.Items))
+	for i := range nodes.Items {
+		if address := nodeAddress(&nodes.Items[i]); address != "" {
+			addresses[nodes.Items[i].Name] = address
+		}
+	}
+	cm := &v1.ConfigMap{}
+	err := j.Client.Get(j.ctx, types.NamespacedName{Namespace: j.Namespace, Name: nodesCMName}, cm)
+	if apierrors.IsNotFound(err) {
+		return j.Client.Create(j.ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: nodesCMName, Namespace: j.Namespace,
+				Labels: map[string]string{"app": "jvb"},
+			},
+			Data: addresses,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = addresses
+	return j.Client.Update(j.ctx, cm)
+}
+
+// nodeAddress returns the ExternalIP of the node, the InternalIP is used for nodes without one.
+func nodeAddress(node *v1.Node) string {
+	var internal string
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case v1.NodeExternalIP:
+			return address.Address
+		case v1.NodeInternalIP:
+			if internal == "" {
+				internal = address.Address
+			}
+		}
+	}
+	return internal
+}
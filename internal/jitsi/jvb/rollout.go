@@ -0,0 +1,301 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jvb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	healthPath            = "/about/health"
+	defaultMaxUnavailable = 1
+)
+
+var errBridgeUnhealthy = errors.New("bridge failed its health check")
+
+// rolloutRevision identifies the spec the bridges are updated to, the replica count is not part of it.
+func (j *JVB) rolloutRevision() string {
+	spec := j.Spec.DeepCopy()
+	spec.Replicas = 0
+	data, err := json.Marshal(spec)
+	if err != nil {
+		j.log.Info("can't calculate rollout revision", "error", err)
+		return ""
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	return fmt.Sprintf("%x", h.Sum32())
+}
+
+// startRollout resets the progress of the rollout and reports whether the spec changed since the last one.
+// The bridges which are already upgraded continue with the new revision.
+func (j *JVB) startRollout() bool {
+	revision := j.rolloutRevision()
+	rollout := j.JVB.Status.Rollout
+	if rollout != nil && rollout.Revision == revision {
+		rollout.UpdatedReplicas, rollout.OutdatedReplicas = 0, 0
+		return false
+	}
+	j.JVB.Status.Rollout = &v1beta1.BridgeRollout{Revision: revision}
+	if rollout != nil {
+		j.JVB.Status.Rollout.Upgrading = rollout.Upgrading
+	}
+	return true
+}
+
+func (j *JVB) isRollingOut() bool {
+	rollout := j.JVB.Status.Rollout
+	if rollout == nil || rollout.Paused {
+		return false
+	}
+	return len(rollout.Upgrading) != 0 || rollout.UpdatedReplicas < j.Spec.Replicas
+}
+
+func (j *JVB) maxUnavailable() int {
+	if j.Spec.MaxUnavailable == nil {
+		return defaultMaxUnavailable
+	}
+	value, err := intstr.GetScaledValueFromIntOrPercent(j.Spec.MaxUnavailable, int(j.Spec.Replicas), false)
+	if err != nil {
+		j.log.Info("can't get max unavailable bridges", "error", err)
+		return defaultMaxUnavailable
+	}
+	return max(value, defaultMaxUnavailable)
+}
+
+// forgetUpgrades drops the upgrades of the bridges which don't exist anymore.
+func (j *JVB) forgetUpgrades(names []string) {
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[name] = true
+	}
+	rollout := j.JVB.Status.Rollout
+	upgrading := make([]v1beta1.BridgeUpgrade, 0, len(rollout.Upgrading))
+	for i := range rollout.Upgrading {
+		if exists[rollout.Upgrading[i].Name] {
+			upgrading = append(upgrading, rollout.Upgrading[i])
+		}
+	}
+	rollout.Upgrading = upgrading
+}
+
+func (j *JVB) upgradeOf(name string) *v1beta1.BridgeUpgrade {
+	rollout := j.JVB.Status.Rollout
+	for i := range rollout.Upgrading {
+		if rollout.Upgrading[i].Name == name {
+			return &rollout.Upgrading[i]
+		}
+	}
+	return nil
+}
+
+// upgrade advances the update of the bridge and reports whether its pod template may be updated.
+// An outdated bridge is updated only after it was drained, the rollout pauses if it isn't healthy afterwards.
+func (j *JVB) upgrade(name string, outdated bool) bool {
+	rollout := j.JVB.Status.Rollout
+	bridge := j.upgradeOf(name)
+	switch {
+	case bridge == nil && outdated:
+		rollout.OutdatedReplicas++
+		return false
+	case bridge == nil:
+		rollout.UpdatedReplicas++
+		return true
+	case bridge.Phase == v1beta1.UpgradePhaseDraining:
+		if rollout.Paused || !j.isDrained(&bridge.BridgeDrain) {
+			rollout.OutdatedReplicas++
+			return false
+		}
+		bridge.Phase = v1beta1.UpgradePhaseUpdating
+		bridge.StartTime = metav1.Now()
+		return true
+	case outdated:
+		bridge.StartTime = metav1.Now()
+		return true
+	}
+	err := j.bridgeHealth(name, bridge.StartTime)
+	switch {
+	case errors.Is(err, errBridgeUnhealthy):
+		if !rollout.Paused {
+			j.log.Info("updated bridge is unhealthy, pausing rollout", "bridge", name, "error", err)
+		}
+		rollout.Paused = true
+		rollout.Message = err.Error()
+	case err != nil:
+		j.log.Info("updated bridge isn't ready yet", "bridge", name, "error", err)
+	default:
+		j.removeUpgrade(name)
+		rollout.UpdatedReplicas++
+	}
+	return true
+}
+
+func (j *JVB) removeUpgrade(name string) {
+	rollout := j.JVB.Status.Rollout
+	for i := range rollout.Upgrading {
+		if rollout.Upgrading[i].Name == name {
+			rollout.Upgrading = append(rollout.Upgrading[:i], rollout.Upgrading[i+1:]...)
+			return
+		}
+	}
+}
+
+// continueRollout puts the next outdated bridges into graceful shutdown, at most maxUnavailable bridges
+// are upgraded at once.
+func (j *JVB) continueRollout(candidates []string) {
+	rollout := j.JVB.Status.Rollout
+	if rollout.Paused {
+		return
+	}
+	for _, name := range candidates {
+		if len(rollout.Upgrading) >= j.maxUnavailable() {
+			return
+		}
+		if j.upgradeOf(name) != nil {
+			continue
+		}
+		count, err := j.conferences(name)
+		if err != nil {
+			j.log.Info("can't get bridge conferences", "bridge", name, "error", err)
+		}
+		rollout.Upgrading = append(rollout.Upgrading, v1beta1.BridgeUpgrade{
+			BridgeDrain: j.shutdownBridge(name, count),
+			Phase:       v1beta1.UpgradePhaseDraining,
+		})
+	}
+}
+
+// byConferences orders the bridges by their conference count, the bridges with the fewest conferences first.
+func (j *JVB) byConferences(names []string) []string {
+	conferences := make(map[string]int32, len(names))
+	for _, name := range names {
+		count, err := j.conferences(name)
+		if err != nil {
+			j.log.Info("can't get bridge conferences", "bridge", name, "error", err)
+		}
+		conferences[name] = count
+	}
+	sorted := append([]string(nil), names...)
+	sort.SliceStable(sorted, func(a, b int) bool { return conferences[sorted[a]] < conferences[sorted[b]] })
+	return sorted
+}
+
+// bridgeHealth checks the pods of the bridge created since the update. It returns errBridgeUnhealthy
+// if the bridge was restarted, e.g. by its liveness probe, or /about/health failed.
+func (j *JVB) bridgeHealth(name string, since metav1.Time) error {
+	var pods v1.PodList
+	if err := j.Client.List(j.ctx, &pods, client.InNamespace(j.Namespace), client.MatchingLabels(j.bridgeSelector(name))); err != nil {
+		return err
+	}
+	start := since.Truncate(time.Second)
+	running := false
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !pod.DeletionTimestamp.IsZero() || pod.CreationTimestamp.Time.Before(start) {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == appName && status.RestartCount > 0 {
+				return fmt.Errorf("%w: %s restarted %d times", errBridgeUnhealthy, pod.Name, status.RestartCount)
+			}
+		}
+		if pod.Status.Phase != v1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		if err := j.checkHealth(pod); err != nil {
+			return err
+		}
+		running = true
+	}
+	if !running {
+		return errBridgeNotRunning
+	}
+	return nil
+}
+
+func (j *JVB) checkHealth(pod *v1.Pod) error {
+	ctx, cancel := context.WithTimeout(j.ctx, colibriRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, podURL(pod, healthPath), http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := colibriClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			j.log.Info("can't close colibri response body", "error", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s %s", errBridgeUnhealthy, pod.Name, resp.Status)
+	}
+	return nil
+}
+
+// rolloutStatefulSet returns the partition of the StatefulSet. It is lowered from the highest ordinal
+// down, so that a pod is only recreated with the new template after it was drained.
+func (j *JVB) rolloutStatefulSet(sts *appsv1.StatefulSet, replicas int32) int32 {
+	fresh := j.startRollout()
+	partition := replicas
+	if strategy := sts.Spec.UpdateStrategy.RollingUpdate; !fresh && strategy != nil && strategy.Partition != nil {
+		partition = min(*strategy.Partition, replicas)
+	}
+	names := make([]string, 0, replicas)
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		names = append(names, podName(ordinal))
+	}
+	j.forgetUpgrades(names)
+	// the update revision is only known once the StatefulSet controller observed the template
+	if fresh || sts.Status.ObservedGeneration < sts.Generation {
+		return partition
+	}
+	var pods v1.PodList
+	if err := j.Client.List(j.ctx, &pods, client.InNamespace(j.Namespace), client.MatchingLabels{bridgeLabel: appName}); err != nil {
+		j.log.Info("can't list jvb pods", "error", err)
+		return partition
+	}
+	revisions := make(map[string]string, len(pods.Items))
+	for i := range pods.Items {
+		revisions[pods.Items[i].Name] = pods.Items[i].Labels[appsv1.ControllerRevisionHashLabelKey]
+	}
+	var candidates []string
+	lowering := true
+	for ordinal := replicas - 1; ordinal >= 0; ordinal-- {
+		name := podName(ordinal)
+		revision, ok := revisions[name]
+		switch {
+		case j.isDraining(name):
+			lowering = false
+		case !ok:
+			// the pod is created with the update revision
+		case j.upgrade(name, revision != sts.Status.UpdateRevision):
+		default:
+			lowering = false
+			candidates = append(candidates, name)
+			continue
+		}
+		if lowering {
+			partition = min(partition, ordinal)
+		}
+	}
+	j.continueRollout(candidates)
+	return partition
+}
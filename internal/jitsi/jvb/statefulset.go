@@ -0,0 +1,308 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jvb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	podNameLabel    = "statefulset.kubernetes.io/pod-name"
+	instancesCMName = "jvb-instances"
+	instancesVolume = "instances"
+)
+
+var errHostNetworkingStatefulSet = errors.New("network mode is not supported in StatefulSet mode")
+
+func (j *JVB) isStatefulSet() bool {
+	return j.Spec.Mode == v1beta1.BridgeModeStatefulSet
+}
+
+// bridgeSelector returns the labels which select the pod of the bridge.
+func (j *JVB) bridgeSelector(name string) map[string]string {
+	if j.isStatefulSet() {
+		return map[string]string{podNameLabel: name}
+	}
+	return map[string]string{bridgeLabel: name}
+}
+
+func podName(ordinal int32) string {
+	return fmt.Sprintf("%s-%d", appName, ordinal)
+}
+
+// setOrdinal selects the pod of the StatefulSet, its port is the one of the bridge jvb-<ordinal+1>
+// in Deployment mode.
+func (j *JVB) setOrdinal(ordinal int32) {
+	j.replica = ordinal + 1
+	j.replicaName = podName(ordinal)
+}
+
+// setStatefulSet selects the resources shared by all pods of the StatefulSet.
+func (j *JVB) setStatefulSet() {
+	j.replica = 0
+	j.replicaName = appName
+}
+
+// updateStatefulSet manages the bridges as one StatefulSet, every pod gets its own Service and port.
+func (j *JVB) updateStatefulSet() error {
+	if j.isHostNetworking() {
+		return fmt.Errorf("%w: %s", errHostNetworkingStatefulSet, j.Spec.NetworkMode)
+	}
+	j.updateOrRecreateConfigMaps()
+	j.setStatefulSet()
+	if err := j.Client.Create(j.ctx, j.prepareHeadlessService()); err != nil && !apierrors.IsAlreadyExists(err) {
+		j.log.Info("can't create jvb headless service", "error", err)
+	}
+	if err := j.updateCustomSIPCM(); err != nil {
+		if apierrors.IsNotFound(err) {
+			if createErr := j.createCustomSIPCM(); createErr != nil && !apierrors.IsAlreadyExists(createErr) {
+				j.log.Info("can't create jvb sip cm", "error", createErr)
+			}
+		} else {
+			j.log.Info("can't update jvb sip cm", "error", err)
+		}
+	}
+	sts, getErr := j.getStatefulSet()
+	if client.IgnoreNotFound(getErr) != nil {
+		j.log.Info("failed to get jvb statefulset", "error", getErr)
+		return getErr
+	}
+	var current int32
+	if getErr == nil && sts.Spec.Replicas != nil {
+		current = *sts.Spec.Replicas
+	}
+	replicas := j.statefulSetReplicas(current)
+	instances := make(map[string]string, replicas)
+	var discovered int32
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		j.setOrdinal(ordinal)
+		if err := j.servicePerInstance(); err != nil {
+			j.log.Info("failed to create service", "error", err)
+		}
+		if j.discoverAddress() && discovered == ordinal {
+			discovered++
+		}
+		instances[j.replicaName] = envFile(j.instanceEnvironments())
+	}
+	// the pods are added once the address of their Service is known
+	if replicas > current {
+		replicas = max(current, discovered)
+	}
+	if err := j.updateInstancesCM(instances); err != nil {
+		j.log.Info("can't update jvb instances cm", "error", err)
+	}
+	j.setStatefulSet()
+	prepared := j.prepareStatefulSet(replicas)
+	if apierrors.IsNotFound(getErr) {
+		j.startRollout()
+		if err := j.Client.Create(j.ctx, prepared); err != nil {
+			j.log.Info("failed to create jvb statefulset", "error", err)
+		}
+	} else {
+		partition := j.rolloutStatefulSet(sts, replicas)
+		sts.Spec.Replicas = prepared.Spec.Replicas
+		sts.Spec.Template = prepared.Spec.Template
+		sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+			Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+		}
+		if err := j.Client.Update(j.ctx, sts); err != nil {
+			j.log.Info("can't update jvb statefulset", "error", err)
+		}
+	}
+	for ordinal := replicas; ordinal < current; ordinal++ {
+		j.setOrdinal(ordinal)
+		if err := j.deleteService(); client.IgnoreNotFound(err) != nil {
+			j.log.Info("failed to delete service", "error", err)
+		}
+	}
+	return j.UpdateStatus()
+}
+
+// statefulSetReplicas returns the replica count of the StatefulSet. It removes the pods with the highest
+// ordinals on scale down, so they are drained first and removed once all of them host no conferences.
+func (j *JVB) statefulSetReplicas(current int32) int32 {
+	if j.Spec.Replicas >= current {
+		j.JVB.Status.Draining = nil
+		return j.Spec.Replicas
+	}
+	desired := max(j.Spec.Replicas, 1)
+	if desired >= current {
+		j.JVB.Status.Draining = nil
+		return current
+	}
+	draining := make([]v1beta1.BridgeDrain, 0, current-desired)
+	drained := true
+	for ordinal := desired; ordinal < current; ordinal++ {
+		bridge := j.drainOf(podName(ordinal))
+		if !j.isDrained(&bridge) {
+			drained = false
+		}
+		draining = append(draining, bridge)
+	}
+	if drained {
+		j.JVB.Status.Draining = nil
+		return desired
+	}
+	j.JVB.Status.Draining = draining
+	return current
+}
+
+// drainOf returns the drain of the pod, the pod is put into graceful shutdown if it isn't draining yet.
+func (j *JVB) drainOf(name string) v1beta1.BridgeDrain {
+	for i := range j.JVB.Status.Draining {
+		if j.JVB.Status.Draining[i].Name == name {
+			return j.JVB.Status.Draining[i]
+		}
+	}
+	count, err := j.conferences(name)
+	if err != nil {
+		j.log.Info("can't get bridge conferences", "bridge", name, "error", err)
+	}
+	return j.shutdownBridge(name, count)
+}
+
+// instanceEnvironments returns the environment of the bridge which depends on its port and Service.
+func (j *JVB) instanceEnvironments() []v1.EnvVar {
+	port := fmt.Sprint(j.port + j.replica)
+	if j.Spec.Port.Protocol != v1.ProtocolTCP && j.Spec.Port.Protocol != v1.ProtocolUDP {
+		return nil
+	}
+	envs := make([]v1.EnvVar, 0, 6) //nolint:mnd //reason: just minimal value
+	if !isHostAddressExist(j.envs) && !j.isHostNetworking() {
+		envs = append(envs, j.addressEnvironments()...)
+	}
+	switch j.Spec.Port.Protocol {
+	case v1.ProtocolTCP:
+		return append(envs,
+			v1.EnvVar{Name: "JVB_PORT", Value: "30300"},
+			v1.EnvVar{Name: "JVB_TCP_PORT", Value: port},
+			v1.EnvVar{Name: "JVB_TCP_MAPPED_PORT", Value: port},
+			v1.EnvVar{Name: "TCP_HARVESTER_PORT", Value: port},
+			v1.EnvVar{Name: "TCP_HARVESTER_MAPPED_PORT", Value: port})
+	default:
+		return append(envs, v1.EnvVar{Name: "JVB_PORT", Value: port})
+	}
+}
+
+// envFile renders the environment as file sourced by the entrypoint of the bridge.
+func envFile(envs []v1.EnvVar) string {
+	var b strings.Builder
+	for _, env := range envs {
+		fmt.Fprintf(&b, "%s='%s'\n", env.Name, strings.ReplaceAll(env.Value, "'", `'\''`))
+	}
+	return b.String()
+}
+
+func (j *JVB) updateInstancesCM(instances map[string]string) error {
+	cm := &v1.ConfigMap{}
+	err := j.Client.Get(j.ctx, types.NamespacedName{Namespace: j.Namespace, Name: instancesCMName}, cm)
+	if apierrors.IsNotFound(err) {
+		return j.Client.Create(j.ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: instancesCMName, Namespace: j.Namespace,
+				Labels: map[string]string{"app": "jvb"},
+			},
+			Data: instances,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = instances
+	return j.Client.Update(j.ctx, cm)
+}
+
+func (j *JVB) prepareStatefulSet(replicas int32) *appsv1.StatefulSet {
+	l := map[string]string{bridgeLabel: appName}
+	template := j.prepareDeploymentSpecWithLabels(l).Template
+	template.Spec.Volumes = append(template.Spec.Volumes, v1.Volume{
+		Name: instancesVolume, VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+			LocalObjectReference: v1.LocalObjectReference{Name: instancesCMName},
+		}},
+	})
+	jvb := &template.Spec.Containers[0]
+	jvb.VolumeMounts = append(jvb.VolumeMounts, v1.VolumeMount{Name: instancesVolume, MountPath: "/instances"})
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            appName,
+			Namespace:       j.Namespace,
+			Labels:          l,
+			Annotations:     j.Annotations,
+			OwnerReferences: j.ownerReferences(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:            &replicas,
+			ServiceName:         appName,
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Selector:            &metav1.LabelSelector{MatchLabels: l},
+			Template:            template,
+		},
+	}
+}
+
+// prepareHeadlessService is the governing Service of the StatefulSet, which gives its pods their network identity.
+func (j *JVB) prepareHeadlessService() *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            appName,
+			Namespace:       j.Namespace,
+			Labels:          map[string]string{"app": "jvb"},
+			OwnerReferences: j.ownerReferences(),
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP:                v1.ClusterIPNone,
+			Selector:                 map[string]string{bridgeLabel: appName},
+			PublishNotReadyAddresses: true,
+			Ports: []v1.ServicePort{{
+				Name: "colibri", Protocol: v1.ProtocolTCP,
+				Port: colibriHTTPPort, TargetPort: intstr.IntOrString{IntVal: colibriHTTPPort},
+			}},
+		},
+	}
+}
+
+func (j *JVB) getStatefulSet() (*appsv1.StatefulSet, error) {
+	sts := &appsv1.StatefulSet{}
+	err := j.Client.Get(j.ctx, types.NamespacedName{Namespace: j.Namespace, Name: appName}, sts)
+	return sts, err
+}
+
+func (j *JVB) deleteStatefulSet() error {
+	sts, err := j.getStatefulSet()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return j.deleteCMs()
+		}
+		j.log.Info("failed to get jvb statefulset", "error", err)
+		return err
+	}
+	for ordinal := int32(0); sts.Spec.Replicas != nil && ordinal < *sts.Spec.Replicas; ordinal++ {
+		j.setOrdinal(ordinal)
+		if err := j.deleteService(); client.IgnoreNotFound(err) != nil {
+			j.log.Info("failed to delete service", "error", err)
+		}
+	}
+	if err := j.Client.Delete(j.ctx, sts); client.IgnoreNotFound(err) != nil {
+		j.log.Info("failed to delete jvb statefulset", "error", err)
+	}
+	if err := j.Client.Delete(j.ctx, j.prepareHeadlessService()); client.IgnoreNotFound(err) != nil {
+		j.log.Info("failed to delete jvb headless service", "error", err)
+	}
+	if err := j.deleteCMs(); client.IgnoreNotFound(err) != nil {
+		j.log.Info("failed to delete jvb cm", "error", err)
+	}
+	return nil
+}
@@ -31,7 +31,10 @@ func (p *Prosody) Create() error {
 		p.log.Info("can't create prosody turn config map", "error", err)
 	}
 	newDeployment := p.prepareDeployment()
-	return p.Client.Create(p.ctx, newDeployment)
+	if err := p.Client.Create(p.ctx, newDeployment); err != nil {
+		return err
+	}
+	return p.UpdateStatus()
 }
 
 func (p *Prosody) createTurnCM() error {
@@ -207,7 +210,16 @@ func (p *Prosody) Update(deployment *appsv1.Deployment) error {
 	deployment.Annotations = p.Annotations
 	deployment.Labels = p.Labels
 	deployment.Spec = p.prepareDeploymentSpec()
-	return p.Client.Update(p.ctx, deployment)
+	if err := p.Client.Update(p.ctx, deployment); err != nil {
+		return err
+	}
+	return p.UpdateStatus()
+}
+
+func (p *Prosody) UpdateStatus() error {
+	p.Prosody.Status.Replicas = p.Spec.Replicas
+	p.Prosody.Status.Selector = labels.SelectorFromSet(p.labels).String()
+	return p.Client.Status().Update(p.ctx, p.Prosody)
 }
 
 func (p *Prosody) updateTurnCM() error {
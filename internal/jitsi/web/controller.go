@@ -5,6 +5,7 @@ package web
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/go-logr/logr"
 	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
@@ -135,5 +136,8 @@ func isSpecUpdated(e event.UpdateEvent) bool {
 	if len(oldObj.Finalizers) < 1 && len(newObj.Finalizers) >= 1 {
 		return false
 	}
+	if !reflect.DeepEqual(oldObj.Status, newObj.Status) {
+		return false
+	}
 	return true
 }
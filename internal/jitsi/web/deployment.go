@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -20,7 +21,10 @@ func (w *Web) Create() error {
 		return svcErr
 	}
 	newDeployment := w.prepareDeployment()
-	return w.Client.Create(w.ctx, newDeployment)
+	if err := w.Client.Create(w.ctx, newDeployment); err != nil {
+		return err
+	}
+	return w.UpdateStatus()
 }
 
 func (w *Web) prepareDeployment() *appsv1.Deployment {
@@ -72,7 +76,16 @@ func (w *Web) Update(deployment *appsv1.Deployment) error {
 	deployment.Annotations = w.Annotations
 	deployment.Labels = w.Labels
 	deployment.Spec = w.prepareDeploymentSpec()
-	return w.Client.Update(w.ctx, deployment)
+	if err := w.Client.Update(w.ctx, deployment); err != nil {
+		return err
+	}
+	return w.UpdateStatus()
+}
+
+func (w *Web) UpdateStatus() error {
+	w.Web.Status.Replicas = w.Spec.Replicas
+	w.Web.Status.Selector = labels.SelectorFromSet(w.labels).String()
+	return w.Client.Status().Update(w.ctx, w.Web)
 }
 
 func (w *Web) Delete() error {
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+)
+
+// sample is a single value returned by a monitoring query.
+type sample struct {
+	timestamp time.Time
+	value     float64
+}
+
+const defaultMaxSampleAge = 5 * time.Minute
+
+// aggregate drops the invalid and stale samples and reduces the rest to the metric value. Samples
+// without a timestamp, e.g. of Flux queries without _time, are never stale.
+func aggregate(samples []sample, reduction v1alpha1.Reduction, spec *v1alpha1.Aggregation, now time.Time) (float64, error) {
+	maxAge, minSamples := defaultMaxSampleAge, 1
+	if spec != nil {
+		if spec.MaxSampleAge != nil {
+			maxAge = spec.MaxSampleAge.Duration
+		}
+		if spec.MinSamples > 0 {
+			minSamples = int(spec.MinSamples)
+		}
+	}
+	valid := make([]sample, 0, len(samples))
+	for _, s := range samples {
+		if !isValid(s.value) {
+			continue
+		}
+		if maxAge > 0 && !s.timestamp.IsZero() && now.Sub(s.timestamp) > maxAge {
+			continue
+		}
+		valid = append(valid, s)
+	}
+	if len(valid) == 0 {
+		return 0, errNoMetricData
+	}
+	if len(valid) < minSamples {
+		return 0, fmt.Errorf("%w: %d of %d", errNotEnoughSamples, len(valid), minSamples)
+	}
+	value, err := reduce(valid, reduction)
+	if err != nil {
+		return 0, err
+	}
+	if !isValid(value) {
+		return 0, errInvalidMetricValue
+	}
+	return value, nil
+}
+
+func isValid(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// reduce reduces the samples to a single value, avg is used if the reduction is not set.
+func reduce(samples []sample, reduction v1alpha1.Reduction) (float64, error) {
+	if len(samples) == 0 {
+		return 0, errNoMetricData
+	}
+	switch reduction {
+	case v1alpha1.ReductionMax:
+		result := samples[0].value
+		for _, s := range samples[1:] {
+			result = max(result, s.value)
+		}
+		return result, nil
+	case v1alpha1.ReductionSum:
+		return sum(samples), nil
+	case v1alpha1.ReductionLast:
+		last := samples[0]
+		for _, s := range samples[1:] {
+			if s.timestamp.After(last.timestamp) {
+				last = s
+			}
+		}
+		return last.value, nil
+	case v1alpha1.ReductionAvg:
+		return sum(samples) / float64(len(samples)), nil
+	default:
+		return sum(samples) / float64(len(samples)), nil
+	}
+}
+
+// reduceByTimestamp reduces the samples of several series which share the same timestamp,
+// the result is sorted by time.
+func reduceByTimestamp(samples []sample, reduction v1alpha1.Reduction) []sample {
+	byTimestamp := make(map[time.Time][]sample)
+	for _, s := range samples {
+		if isValid(s.value) {
+			byTimestamp[s.timestamp] = append(byTimestamp[s.timestamp], s)
+		}
+	}
+	result := make([]sample, 0, len(byTimestamp))
+	for timestamp, group := range byTimestamp {
+		value, err := reduce(group, reduction)
+		if err != nil {
+			continue
+		}
+		result = append(result, sample{timestamp: timestamp, value: value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].timestamp.Before(result[j].timestamp) })
+	return result
+}
+
+func sum(samples []sample) float64 {
+	var result float64
+	for _, s := range samples {
+		result += s.value
+	}
+	return result
+}
+
+// targetOf returns the target value of the metric.
+func targetOf(metric v1alpha1.Metric) float64 {
+	if metric.Target != nil {
+		return metric.Target.AsApproximateFloat64()
+	}
+	if metric.Custom != nil && metric.Custom.Target != nil {
+		return metric.Custom.Target.AsApproximateFloat64()
+	}
+	return float64(metric.TargetAverageUtilization)
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var aggregationNow = time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+func sampleAt(age time.Duration, value float64) sample {
+	return sample{timestamp: aggregationNow.Add(-age), value: value}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   []sample
+		reduction v1alpha1.Reduction
+		spec      *v1alpha1.Aggregation
+		want      float64
+		wantErr   error
+	}{
+		{
+			name:    "average by default",
+			samples: []sample{sampleAt(time.Minute, 2), sampleAt(time.Minute, 4)},
+			want:    3,
+		},
+		{
+			name:    "NaN and Inf are dropped",
+			samples: []sample{sampleAt(time.Minute, math.NaN()), sampleAt(time.Minute, math.Inf(1)), sampleAt(time.Minute, math.Inf(-1)), sampleAt(time.Minute, 5)},
+			want:    5,
+		},
+		{
+			name:    "only invalid samples",
+			samples: []sample{sampleAt(time.Minute, math.NaN()), sampleAt(time.Minute, math.Inf(1))},
+			wantErr: errNoMetricData,
+		},
+		{
+			name:    "stale samples are dropped",
+			samples: []sample{sampleAt(10*time.Minute, 100), sampleAt(time.Minute, 4)},
+			want:    4,
+		},
+		{
+			name:    "sample at the max age is kept",
+			samples: []sample{sampleAt(defaultMaxSampleAge, 2), sampleAt(time.Minute, 4)},
+			want:    3,
+		},
+		{
+			name:    "only stale samples",
+			samples: []sample{sampleAt(10*time.Minute, 100)},
+			wantErr: errNoMetricData,
+		},
+		{
+			name:    "custom max age",
+			samples: []sample{sampleAt(2*time.Minute, 100), sampleAt(30*time.Second, 4)},
+			spec:    &v1alpha1.Aggregation{MaxSampleAge: &metav1.Duration{Duration: time.Minute}},
+			want:    4,
+		},
+		{
+			name:    "max age disabled",
+			samples: []sample{sampleAt(time.Hour, 2), sampleAt(time.Minute, 4)},
+			spec:    &v1alpha1.Aggregation{MaxSampleAge: &metav1.Duration{}},
+			want:    3,
+		},
+		{
+			name:    "samples without timestamp are never stale",
+			samples: []sample{{value: 6}, sampleAt(10*time.Minute, 100)},
+			want:    6,
+		},
+		{
+			name:    "enough samples",
+			samples: []sample{sampleAt(time.Minute, 2), sampleAt(time.Minute, 4)},
+			spec:    &v1alpha1.Aggregation{MinSamples: 2},
+			want:    3,
+		},
+		{
+			name:    "not enough samples",
+			samples: []sample{sampleAt(time.Minute, 2), sampleAt(time.Minute, math.NaN())},
+			spec:    &v1alpha1.Aggregation{MinSamples: 2},
+			wantErr: errNotEnoughSamples,
+		},
+		{
+			name:    "stale samples don't count",
+			samples: []sample{sampleAt(time.Minute, 2), sampleAt(10*time.Minute, 4)},
+			spec:    &v1alpha1.Aggregation{MinSamples: 2},
+			wantErr: errNotEnoughSamples,
+		},
+		{
+			name:    "no samples",
+			wantErr: errNoMetricData,
+		},
+		{
+			name:      "overflowing sum",
+			samples:   []sample{sampleAt(time.Minute, math.MaxFloat64), sampleAt(time.Minute, math.MaxFloat64)},
+			reduction: v1alpha1.ReductionSum,
+			wantErr:   errInvalidMetricValue,
+		},
+		{
+			name:      "reduction is applied",
+			samples:   []sample{sampleAt(time.Minute, 2), sampleAt(time.Minute, 4)},
+			reduction: v1alpha1.ReductionMax,
+			want:      4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := aggregate(tt.samples, tt.reduction, tt.spec, aggregationNow)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("aggregate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("aggregate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("aggregate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReduce(t *testing.T) {
+	samples := []sample{sampleAt(2*time.Minute, 3), sampleAt(0, 1), sampleAt(time.Minute, 8)}
+	tests := []struct {
+		name      string
+		samples   []sample
+		reduction v1alpha1.Reduction
+		want      float64
+		wantErr   error
+	}{
+		{name: "avg", samples: samples, reduction: v1alpha1.ReductionAvg, want: 4},
+		{name: "avg by default", samples: samples, want: 4},
+		{name: "max", samples: samples, reduction: v1alpha1.ReductionMax, want: 8},
+		{name: "max of negative values", samples: []sample{sampleAt(0, -3), sampleAt(0, -1)}, reduction: v1alpha1.ReductionMax, want: -1},
+		{name: "sum", samples: samples, reduction: v1alpha1.ReductionSum, want: 12},
+		{name: "last", samples: samples, reduction: v1alpha1.ReductionLast, want: 1},
+		{name: "single sample", samples: samples[:1], reduction: v1alpha1.ReductionLast, want: 3},
+		{name: "empty", reduction: v1alpha1.ReductionSum, wantErr: errNoMetricData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reduce(tt.samples, tt.reduction)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("reduce() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reduce() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("reduce() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReduceByTimestamp(t *testing.T) {
+	samples := []sample{
+		sampleAt(0, 4), sampleAt(time.Minute, 1), sampleAt(0, 2), sampleAt(time.Minute, 3), sampleAt(2*time.Minute, math.NaN()),
+	}
+	got := reduceByTimestamp(samples, v1alpha1.ReductionSum)
+	want := []sample{sampleAt(time.Minute, 4), sampleAt(0, 6)}
+	if len(got) != len(want) {
+		t.Fatalf("reduceByTimestamp() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].timestamp.Equal(want[i].timestamp) || got[i].value != want[i].value {
+			t.Errorf("reduceByTimestamp()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
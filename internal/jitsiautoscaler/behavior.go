@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	defaultScaleUpStabilizationWindowSeconds   int32 = 0
+	defaultScaleDownStabilizationWindowSeconds int32 = 300
+	defaultScalingPolicyPeriodSeconds          int32 = 15
+	defaultScaleUpPodsValue                    int32 = 4
+	defaultScalingPercentValue                 int32 = 100
+)
+
+// scaleUpRules returns the scale up rules of the autoscaler with the autoscaling/v2 defaults applied.
+func scaleUpRules(b *v1alpha1.Behavior) v1alpha1.ScalingRules {
+	rules := v1alpha1.ScalingRules{}
+	if b != nil && b.ScaleUp != nil {
+		rules = *b.ScaleUp.DeepCopy()
+	}
+	if rules.StabilizationWindowSeconds == nil {
+		rules.StabilizationWindowSeconds = ptr.To(defaultScaleUpStabilizationWindowSeconds)
+	}
+	if rules.SelectPolicy == nil {
+		rules.SelectPolicy = ptr.To(v1alpha1.MaxChangePolicySelect)
+	}
+	if len(rules.Policies) == 0 {
+		rules.Policies = []v1alpha1.ScalingPolicy{
+			{Type: v1alpha1.PodsScalingPolicy, Value: defaultScaleUpPodsValue, PeriodSeconds: defaultScalingPolicyPeriodSeconds},
+			{Type: v1alpha1.PercentScalingPolicy, Value: defaultScalingPercentValue, PeriodSeconds: defaultScalingPolicyPeriodSeconds},
+		}
+	}
+	return rules
+}
+
+// scaleDownRules returns the scale down rules of the autoscaler with the autoscaling/v2 defaults applied.
+func scaleDownRules(b *v1alpha1.Behavior) v1alpha1.ScalingRules {
+	rules := v1alpha1.ScalingRules{}
+	if b != nil && b.ScaleDown != nil {
+		rules = *b.ScaleDown.DeepCopy()
+	}
+	if rules.StabilizationWindowSeconds == nil {
+		rules.StabilizationWindowSeconds = ptr.To(defaultScaleDownStabilizationWindowSeconds)
+	}
+	if rules.SelectPolicy == nil {
+		rules.SelectPolicy = ptr.To(v1alpha1.MaxChangePolicySelect)
+	}
+	if len(rules.Policies) == 0 {
+		rules.Policies = []v1alpha1.ScalingPolicy{
+			{Type: v1alpha1.PercentScalingPolicy, Value: defaultScalingPercentValue, PeriodSeconds: defaultScalingPolicyPeriodSeconds},
+		}
+	}
+	return rules
+}
+
+// applyBehavior records the recommendation in the autoscaler status and returns the replica count
+// allowed by the stabilization windows and scaling policies.
+func applyBehavior(jas *v1alpha1.AutoScaler, currentReplicas, recommendation int32, now time.Time) int32 {
+	up, down := scaleUpRules(jas.Spec.Behavior), scaleDownRules(jas.Spec.Behavior)
+	recordRecommendation(jas, up, down, recommendation, now)
+	desired := stabilizeRecommendation(jas.Status.Recommendations, up, down, currentReplicas, recommendation, now)
+	switch {
+	case desired > currentReplicas:
+		desired = min(desired, scaleUpLimit(jas.Status.ScaleEvents, up, currentReplicas, now))
+	case desired < currentReplicas:
+		desired = max(desired, scaleDownLimit(jas.Status.ScaleEvents, down, currentReplicas, now))
+	}
+	return desired
+}
+
+// behaviorInterval returns the shortest policy period or stabilization window of the behavior, the autoscaler
+// is evaluated at least that often, so the windows hold more than one recommendation. It is zero without behavior.
+func behaviorInterval(b *v1alpha1.Behavior) time.Duration {
+	if b == nil {
+		return 0
+	}
+	var shortest int32
+	for _, rules := range []v1alpha1.ScalingRules{scaleUpRules(b), scaleDownRules(b)} {
+		periods := []int32{*rules.StabilizationWindowSeconds}
+		for _, policy := range rules.Policies {
+			periods = append(periods, policy.PeriodSeconds)
+		}
+		for _, period := range periods {
+			if period > 0 && (shortest == 0 || period < shortest) {
+				shortest = period
+			}
+		}
+	}
+	return time.Duration(shortest) * time.Second
+}
+
+// stabilizeRecommendation picks the safest recommendation within the windows: the lowest one
+// for scaling up and the highest one for scaling down.
+func stabilizeRecommendation(history []v1alpha1.Recommendation, up, down v1alpha1.ScalingRules,
+	currentReplicas, recommendation int32, now time.Time,
+) int32 {
+	upRecommendation, downRecommendation := recommendation, recommendation
+	for _, rec := range inWindow(history, *up.StabilizationWindowSeconds, now) {
+		upRecommendation = min(upRecommendation, rec.Replicas)
+	}
+	for _, rec := range inWindow(history, *down.StabilizationWindowSeconds, now) {
+		downRecommendation = max(downRecommendation, rec.Replicas)
+	}
+	stabilized := currentReplicas
+	if stabilized < upRecommendation {
+		stabilized = upRecommendation
+	}
+	if stabilized > downRecommendation {
+		stabilized = downRecommendation
+	}
+	return stabilized
+}
+
+func scaleUpLimit(events []v1alpha1.ScaleEvent, rules v1alpha1.ScalingRules, currentReplicas int32, now time.Time) int32 {
+	if *rules.SelectPolicy == v1alpha1.DisabledPolicySelect {
+		return currentReplicas
+	}
+	var result int32 = math.MinInt32
+	if *rules.SelectPolicy == v1alpha1.MinChangePolicySelect {
+		result = math.MaxInt32
+	}
+	for _, policy := range rules.Policies {
+		periodStartReplicas := currentReplicas - replicasChangedInPeriod(events, policy.PeriodSeconds, now, true)
+		var limit int32
+		switch policy.Type {
+		case v1alpha1.PodsScalingPolicy:
+			limit = periodStartReplicas + policy.Value
+		case v1alpha1.PercentScalingPolicy:
+			limit = int32(math.Ceil(float64(periodStartReplicas) * (1 + float64(policy.Value)/100)))
+		default:
+			continue
+		}
+		if *rules.SelectPolicy == v1alpha1.MinChangePolicySelect {
+			result = min(result, limit)
+		} else {
+			result = max(result, limit)
+		}
+	}
+	return max(result, currentReplicas)
+}
+
+func scaleDownLimit(events []v1alpha1.ScaleEvent, rules v1alpha1.ScalingRules, currentReplicas int32, now time.Time) int32 {
+	if *rules.SelectPolicy == v1alpha1.DisabledPolicySelect {
+		return currentReplicas
+	}
+	var result int32 = math.MaxInt32
+	if *rules.SelectPolicy == v1alpha1.MinChangePolicySelect {
+		result = math.MinInt32
+	}
+	for _, policy := range rules.Policies {
+		periodStartReplicas := currentReplicas - replicasChangedInPeriod(events, policy.PeriodSeconds, now, false)
+		var limit int32
+		switch policy.Type {
+		case v1alpha1.PodsScalingPolicy:
+			limit = periodStartReplicas - policy.Value
+		case v1alpha1.PercentScalingPolicy:
+			limit = int32(math.Floor(float64(periodStartReplicas) * (1 - float64(policy.Value)/100)))
+		default:
+			continue
+		}
+		if *rules.SelectPolicy == v1alpha1.MinChangePolicySelect {
+			result = max(result, limit)
+		} else {
+			result = min(result, limit)
+		}
+	}
+	return min(result, currentReplicas)
+}
+
+// replicasChangedInPeriod sums the replica changes in one direction during the last periodSeconds.
+func replicasChangedInPeriod(events []v1alpha1.ScaleEvent, periodSeconds int32, now time.Time, up bool) int32 {
+	var changed int32
+	cutoff := now.Add(-time.Duration(periodSeconds) * time.Second)
+	for _, event := range events {
+		if !event.Timestamp.After(cutoff) {
+			continue
+		}
+		if up == (event.ReplicaChange > 0) {
+			changed += event.ReplicaChange
+		}
+	}
+	return changed
+}
+
+// inWindow returns the recommendations of the stabilization window. A recommendation holds until the next one,
+// so the last one before the window is in effect at its start and belongs to it as well. Otherwise a window
+// shorter than the interval of the autoscaler would never hold more than the current recommendation.
+func inWindow(history []v1alpha1.Recommendation, windowSeconds int32, now time.Time) []v1alpha1.Recommendation {
+	if windowSeconds <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-time.Duration(windowSeconds) * time.Second)
+	var window []v1alpha1.Recommendation
+	var previous *v1alpha1.Recommendation
+	for i := range history {
+		rec := &history[i]
+		switch {
+		case rec.Timestamp.After(cutoff):
+			window = append(window, *rec)
+		case previous == nil || rec.Timestamp.After(previous.Timestamp.Time):
+			previous = rec
+		}
+	}
+	if previous != nil {
+		window = append(window, *previous)
+	}
+	return window
+}
+
+// recordRecommendation appends the recommendation and drops the ones which are in none of both windows.
+func recordRecommendation(jas *v1alpha1.AutoScaler, up, down v1alpha1.ScalingRules, replicas int32, now time.Time) {
+	window := max(*up.StabilizationWindowSeconds, *down.StabilizationWindowSeconds)
+	history := append(inWindow(jas.Status.Recommendations, window, now),
+		v1alpha1.Recommendation{Timestamp: metav1.NewTime(now), Replicas: replicas})
+	sort.Slice(history, func(i, j int) bool { return history[i].Timestamp.Before(&history[j].Timestamp) })
+	jas.Status.Recommendations = history
+}
+
+// recordScaleEvent appends the replica change and drops the events older than the longest policy period.
+func recordScaleEvent(jas *v1alpha1.AutoScaler, replicaChange int32, now time.Time) {
+	var period int32
+	for _, rules := range []v1alpha1.ScalingRules{scaleUpRules(jas.Spec.Behavior), scaleDownRules(jas.Spec.Behavior)} {
+		for _, policy := range rules.Policies {
+			period = max(period, policy.PeriodSeconds)
+		}
+	}
+	cutoff := now.Add(-time.Duration(period) * time.Second)
+	events := make([]v1alpha1.ScaleEvent, 0, len(jas.Status.ScaleEvents)+1)
+	for _, event := range jas.Status.ScaleEvents {
+		if event.Timestamp.After(cutoff) {
+			events = append(events, event)
+		}
+	}
+	jas.Status.ScaleEvents = append(events, v1alpha1.ScaleEvent{Timestamp: metav1.NewTime(now), ReplicaChange: replicaChange})
+}
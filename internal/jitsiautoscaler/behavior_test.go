@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var behaviorNow = time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+func recommendationAt(secondsAgo int, replicas int32) v1alpha1.Recommendation {
+	return v1alpha1.Recommendation{Timestamp: metav1.NewTime(behaviorNow.Add(-time.Duration(secondsAgo) * time.Second)), Replicas: replicas}
+}
+
+func scaleEventAt(secondsAgo int, replicaChange int32) v1alpha1.ScaleEvent {
+	return v1alpha1.ScaleEvent{Timestamp: metav1.NewTime(behaviorNow.Add(-time.Duration(secondsAgo) * time.Second)), ReplicaChange: replicaChange}
+}
+
+func TestStabilizeRecommendation(t *testing.T) {
+	tests := []struct {
+		name            string
+		behavior        *v1alpha1.Behavior
+		history         []v1alpha1.Recommendation
+		currentReplicas int32
+		recommendation  int32
+		want            int32
+	}{
+		{
+			name:            "scale up without window",
+			history:         []v1alpha1.Recommendation{recommendationAt(60, 2), recommendationAt(0, 5)},
+			currentReplicas: 2, recommendation: 5, want: 5,
+		},
+		{
+			name:            "scale down held by the window",
+			history:         []v1alpha1.Recommendation{recommendationAt(120, 4), recommendationAt(0, 2)},
+			currentReplicas: 4, recommendation: 2, want: 4,
+		},
+		{
+			name: "scale down to the highest recommendation of the window",
+			history: []v1alpha1.Recommendation{recommendationAt(600, 5), recommendationAt(350, 3), recommendationAt(200, 2),
+				recommendationAt(0, 2)},
+			currentReplicas: 5, recommendation: 2, want: 3,
+		},
+		{
+			name:            "previous recommendation holds at the window start",
+			history:         []v1alpha1.Recommendation{recommendationAt(1200, 6), recommendationAt(600, 4), recommendationAt(0, 2)},
+			currentReplicas: 4, recommendation: 2, want: 4,
+		},
+		{
+			name:            "scale down after two recommendations",
+			history:         []v1alpha1.Recommendation{recommendationAt(1200, 4), recommendationAt(600, 2), recommendationAt(0, 2)},
+			currentReplicas: 4, recommendation: 2, want: 2,
+		},
+		{
+			name:            "scale down without window",
+			behavior:        &v1alpha1.Behavior{ScaleDown: &v1alpha1.ScalingRules{StabilizationWindowSeconds: ptr.To[int32](0)}},
+			history:         []v1alpha1.Recommendation{recommendationAt(60, 4), recommendationAt(0, 2)},
+			currentReplicas: 4, recommendation: 2, want: 2,
+		},
+		{
+			name:            "scale up held by the window",
+			behavior:        &v1alpha1.Behavior{ScaleUp: &v1alpha1.ScalingRules{StabilizationWindowSeconds: ptr.To[int32](300)}},
+			history:         []v1alpha1.Recommendation{recommendationAt(600, 2), recommendationAt(0, 5)},
+			currentReplicas: 2, recommendation: 5, want: 2,
+		},
+		{
+			name:            "scale up to the lowest recommendation of the window",
+			behavior:        &v1alpha1.Behavior{ScaleUp: &v1alpha1.ScalingRules{StabilizationWindowSeconds: ptr.To[int32](300)}},
+			history:         []v1alpha1.Recommendation{recommendationAt(600, 3), recommendationAt(0, 5)},
+			currentReplicas: 2, recommendation: 5, want: 3,
+		},
+		{
+			name:            "never beyond the current replicas",
+			history:         []v1alpha1.Recommendation{recommendationAt(120, 6), recommendationAt(0, 3)},
+			currentReplicas: 4, recommendation: 3, want: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down := scaleUpRules(tt.behavior), scaleDownRules(tt.behavior)
+			got := stabilizeRecommendation(tt.history, up, down, tt.currentReplicas, tt.recommendation, behaviorNow)
+			if got != tt.want {
+				t.Errorf("stabilizeRecommendation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleUpLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		rules           v1alpha1.ScalingRules
+		events          []v1alpha1.ScaleEvent
+		currentReplicas int32
+		want            int32
+	}{
+		{name: "default pods policy", currentReplicas: 2, want: 6},
+		{name: "default percent policy", currentReplicas: 10, want: 20},
+		{
+			name:            "change in the period counts",
+			events:          []v1alpha1.ScaleEvent{scaleEventAt(10, 4)},
+			currentReplicas: 6, want: 6,
+		},
+		{
+			name:            "change before the period is ignored",
+			events:          []v1alpha1.ScaleEvent{scaleEventAt(20, 4)},
+			currentReplicas: 6, want: 12,
+		},
+		{
+			name:            "scale down in the period is ignored",
+			events:          []v1alpha1.ScaleEvent{scaleEventAt(10, -2)},
+			currentReplicas: 2, want: 6,
+		},
+		{
+			name: "min select policy",
+			rules: v1alpha1.ScalingRules{SelectPolicy: ptr.To(v1alpha1.MinChangePolicySelect), Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 4, PeriodSeconds: 60},
+				{Type: v1alpha1.PercentScalingPolicy, Value: 50, PeriodSeconds: 60},
+			}},
+			currentReplicas: 3, want: 5,
+		},
+		{
+			name:            "disabled",
+			rules:           v1alpha1.ScalingRules{SelectPolicy: ptr.To(v1alpha1.DisabledPolicySelect)},
+			currentReplicas: 3, want: 3,
+		},
+		{
+			name: "never below the current replicas",
+			rules: v1alpha1.ScalingRules{Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 600},
+			}},
+			events:          []v1alpha1.ScaleEvent{scaleEventAt(300, 3)},
+			currentReplicas: 5, want: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := scaleUpRules(&v1alpha1.Behavior{ScaleUp: &tt.rules})
+			if got := scaleUpLimit(tt.events, rules, tt.currentReplicas, behaviorNow); got != tt.want {
+				t.Errorf("scaleUpLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleDownLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		rules           v1alpha1.ScalingRules
+		events          []v1alpha1.ScaleEvent
+		currentReplicas int32
+		want            int32
+	}{
+		{name: "default percent policy", currentReplicas: 4, want: 0},
+		{
+			name: "pods policy",
+			rules: v1alpha1.ScalingRules{Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 600},
+			}},
+			currentReplicas: 4, want: 3,
+		},
+		{
+			name: "change in the period counts",
+			rules: v1alpha1.ScalingRules{Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 600},
+			}},
+			events:          []v1alpha1.ScaleEvent{scaleEventAt(300, -1)},
+			currentReplicas: 3, want: 3,
+		},
+		{
+			name: "change before the period is ignored",
+			rules: v1alpha1.ScalingRules{Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 600},
+			}},
+			events:          []v1alpha1.ScaleEvent{scaleEventAt(900, -1)},
+			currentReplicas: 3, want: 2,
+		},
+		{
+			name: "percent policy rounded down",
+			rules: v1alpha1.ScalingRules{Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PercentScalingPolicy, Value: 10, PeriodSeconds: 60},
+			}},
+			currentReplicas: 5, want: 4,
+		},
+		{
+			name: "max select policy",
+			rules: v1alpha1.ScalingRules{Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 60},
+				{Type: v1alpha1.PercentScalingPolicy, Value: 50, PeriodSeconds: 60},
+			}},
+			currentReplicas: 8, want: 4,
+		},
+		{
+			name: "min select policy",
+			rules: v1alpha1.ScalingRules{SelectPolicy: ptr.To(v1alpha1.MinChangePolicySelect), Policies: []v1alpha1.ScalingPolicy{
+				{Type: v1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 60},
+				{Type: v1alpha1.PercentScalingPolicy, Value: 50, PeriodSeconds: 60},
+			}},
+			currentReplicas: 8, want: 7,
+		},
+		{
+			name:            "disabled",
+			rules:           v1alpha1.ScalingRules{SelectPolicy: ptr.To(v1alpha1.DisabledPolicySelect)},
+			currentReplicas: 3, want: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := scaleDownRules(&v1alpha1.Behavior{ScaleDown: &tt.rules})
+			if got := scaleDownLimit(tt.events, rules, tt.currentReplicas, behaviorNow); got != tt.want {
+				t.Errorf("scaleDownLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordRecommendation(t *testing.T) {
+	jas := &v1alpha1.AutoScaler{Status: v1alpha1.AutoScalerStatus{Recommendations: []v1alpha1.Recommendation{
+		recommendationAt(1800, 6), recommendationAt(1200, 5), recommendationAt(600, 4), recommendationAt(100, 3),
+	}}}
+	recordRecommendation(jas, scaleUpRules(nil), scaleDownRules(nil), 2, behaviorNow)
+	// the recommendation before the window is kept, since it still holds at the window start
+	want := []int32{4, 3, 2}
+	got := make([]int32, 0, len(jas.Status.Recommendations))
+	for _, rec := range jas.Status.Recommendations {
+		got = append(got, rec.Replicas)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("recommendations = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("recommendations = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestApplyBehaviorDefaultInterval(t *testing.T) {
+	jas := &v1alpha1.AutoScaler{}
+	replicas := int32(4)
+	// evaluated every defaultRepeatInterval, longer than the default scale down window
+	for i, recommendation := range []int32{4, 2, 2} {
+		now := behaviorNow.Add(time.Duration(i) * defaultRepeatInterval)
+		desired := applyBehavior(jas, replicas, recommendation, now)
+		if desired != replicas {
+			recordScaleEvent(jas, desired-replicas, now)
+			replicas = desired
+		}
+		if want := []int32{4, 4, 2}[i]; replicas != want {
+			t.Fatalf("evaluation %d: replicas = %d, want %d", i, replicas, want)
+		}
+	}
+}
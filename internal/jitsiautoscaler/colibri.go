@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	colibriHTTPPort       = 8080
+	colibriStatsPath      = "/colibri/stats"
+	colibriRequestTimeout = 5 * time.Second
+)
+
+var (
+	errColibriResponse  = errors.New("unexpected response from bridge")
+	errSelectorNotExist = errors.New("scale target doesn't report a selector")
+)
+
+// colibriFields maps the metric names to the fields of the colibri statistics.
+var colibriFields = map[v1alpha1.MetricName]string{
+	v1alpha1.ResourceConference:      "conferences",
+	v1alpha1.ResourceParticipants:    "participants",
+	v1alpha1.ResourceStressLevel:     "stress_level",
+	v1alpha1.ResourceBitRateDownload: "bit_rate_download",
+	v1alpha1.ResourceBitRateUpload:   "bit_rate_upload",
+}
+
+// colibri reads the statistics of every running bridge pod selected by the scale target.
+type colibri struct {
+	log        logr.Logger
+	client     client.Client
+	jas        *v1alpha1.AutoScaler
+	httpClient *http.Client
+}
+
+func newColibriProvider(cfg providerConfig) (MetricsProvider, error) {
+	return &colibri{
+		log:    cfg.log,
+		client: cfg.client,
+		jas:    cfg.jas,
+		httpClient: &http.Client{
+			Transport: newAuthRoundTripper(cfg.transport, cfg.creds, true),
+			Timeout:   colibriRequestTimeout,
+		},
+	}, nil
+}
+
+// Samples returns the field of all bridges, the query of a custom metric is the name of the field,
+// e.g. "largest_conference".
+func (c *colibri) Samples(ctx context.Context, metric v1alpha1.Metric) ([]sample, v1alpha1.Reduction, error) {
+	field, reduction := colibriFields[metric.Name], v1alpha1.ReductionAvg
+	if metric.Name == v1alpha1.ResourceCustom {
+		if metric.Custom == nil {
+			return nil, "", errCustomQueryNotSet
+		}
+		field, reduction = metric.Custom.Query, metric.Custom.Reduction
+	}
+	if field == "" {
+		return nil, "", fmt.Errorf("%w: %s", errUnknownMetric, metric.Name)
+	}
+	stats, err := c.stats(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	samples := make([]sample, 0, len(stats))
+	for _, bridge := range stats {
+		if value, ok := toFloat(bridge[field]); ok {
+			samples = append(samples, sample{timestamp: time.Now(), value: value})
+		}
+	}
+	return samples, reduction, nil
+}
+
+// Series is not supported, the bridges only know their current statistics.
+func (c *colibri) Series(_ context.Context, metric v1alpha1.Metric, _, _ time.Time) ([]sample, error) {
+	return nil, fmt.Errorf("%w: %s", errPredictionNotSupported, metric.Name)
+}
+
+// stats returns the statistics of all bridges which answered, bridges which failed are skipped.
+func (c *colibri) stats(ctx context.Context) ([]map[string]interface{}, error) {
+	selector, err := c.selector(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.jas.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	stats := make([]map[string]interface{}, 0, len(pods.Items))
+	errs := make([]error, 0, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		bridge, err := c.bridgeStats(ctx, pod.Status.PodIP)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pod.Name, err))
+			continue
+		}
+		stats = append(stats, bridge)
+	}
+	if len(stats) == 0 {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		return nil, errNoMetricData
+	}
+	return stats, nil
+}
+
+// selector returns the selector of the bridge pods reported by the scale subresource of the target.
+func (c *colibri) selector(ctx context.Context) (labels.Selector, error) {
+	target, err := getScaleTarget(ctx, c.client, c.jas)
+	if err != nil {
+		return nil, err
+	}
+	if target.scale.Status.Selector == "" {
+		return nil, fmt.Errorf("%w: %s", errSelectorNotExist, target.object.GetName())
+	}
+	return labels.Parse(target.scale.Status.Selector)
+}
+
+func (c *colibri) bridgeStats(ctx context.Context, podIP string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(podIP, strconv.Itoa(colibriHTTPPort)), colibriStatsPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.log.Info("can't close colibri response body", "error", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errColibriResponse, resp.Status)
+	}
+	stats := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	colibriScalePath = "/apis/jitsi.meeting.ko/v1beta1/namespaces/default/jvbs/jvb/scale"
+	colibriPodsPath  = "/api/v1/namespaces/default/pods"
+)
+
+// bridgeTransport sends the requests to the bridges to the fake colibri server and records their hosts.
+type bridgeTransport struct {
+	mu     sync.Mutex
+	server *url.URL
+	hosts  []string
+}
+
+func (rt *bridgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.hosts = append(rt.hosts, req.URL.Host)
+	rt.mu.Unlock()
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Bridge", req.URL.Host)
+	req.URL.Scheme, req.URL.Host = rt.server.Scheme, rt.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newColibriServer(t *testing.T, stats map[string]string) *bridgeTransport {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := stats[r.Header.Get("X-Bridge")]
+		if r.URL.Path != colibriStatsPath || !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("can't parse server url: %v", err)
+	}
+	return &bridgeTransport{server: target}
+}
+
+func bridgePod(name, ip string, phase corev1.PodPhase) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: phase, PodIP: ip},
+	}
+}
+
+func newTestColibriProvider(t *testing.T, selector string, pods []corev1.Pod, stats map[string]string) (
+	MetricsProvider, *fakeAPIServer, *bridgeTransport) {
+	t.Helper()
+	c, api := newFakeClient(t, map[string]interface{}{
+		colibriScalePath: testScale("jvb", int32(len(pods)), selector),
+		colibriPodsPath: &corev1.PodList{
+			TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+			Items:    pods,
+		},
+	})
+	transport := newColibriServer(t, stats)
+	jas := &v1alpha1.AutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "jas", Namespace: "default"},
+		Spec: v1alpha1.AutoScalerSpec{
+			MonitoringType: v1alpha1.MonitoringColibri,
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "jvb"},
+		},
+	}
+	provider, err := newColibriProvider(providerConfig{
+		client: c, jas: jas, log: logr.Discard(), creds: credentials{headers: map[string]string{}}, transport: transport,
+	})
+	if err != nil {
+		t.Fatalf("can't create provider: %v", err)
+	}
+	return provider, api, transport
+}
+
+func TestColibriProviderSamples(t *testing.T) {
+	pods := []corev1.Pod{
+		bridgePod("jvb-1", "10.0.0.1", corev1.PodRunning),
+		bridgePod("jvb-2", "10.0.0.2", corev1.PodRunning),
+		bridgePod("jvb-3", "", corev1.PodPending),
+		bridgePod("jvb-4", "10.0.0.4", corev1.PodRunning),
+	}
+	stats := map[string]string{
+		"10.0.0.1:8080": `{"conferences": 3, "participants": 10, "largest_conference": 6}`,
+		"10.0.0.2:8080": `{"conferences": 1, "participants": 2, "largest_conference": 2}`,
+	}
+	tests := []struct {
+		name      string
+		metric    v1alpha1.Metric
+		want      []float64
+		reduction v1alpha1.Reduction
+		wantErr   error
+	}{
+		{
+			name:      "jitsi metric",
+			metric:    v1alpha1.Metric{Name: v1alpha1.ResourceConference},
+			want:      []float64{1, 3},
+			reduction: v1alpha1.ReductionAvg,
+		},
+		{
+			name: "custom field",
+			metric: v1alpha1.Metric{Name: v1alpha1.ResourceCustom,
+				Custom: &v1alpha1.CustomMetric{Query: "largest_conference", Reduction: v1alpha1.ReductionMax}},
+			want:      []float64{2, 6},
+			reduction: v1alpha1.ReductionMax,
+		},
+		{
+			name:      "missing field",
+			metric:    v1alpha1.Metric{Name: v1alpha1.ResourceCustom, Custom: &v1alpha1.CustomMetric{Query: "unknown"}},
+			want:      []float64{},
+			reduction: "",
+		},
+		{
+			name:    "unknown metric",
+			metric:  v1alpha1.Metric{Name: v1alpha1.ResourceCPU},
+			wantErr: errUnknownMetric,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, api, transport := newTestColibriProvider(t, "jitsi-jvb", pods, stats)
+			samples, reduction, err := provider.Samples(context.Background(), tt.metric)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Samples() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Samples() error = %v", err)
+			}
+			if got := api.lastRequest(); got.URL.Path != colibriPodsPath || got.URL.Query().Get("labelSelector") != "jitsi-jvb" {
+				t.Errorf("pods are listed by %s?%s, want the selector of the scale target", got.URL.Path, got.URL.RawQuery)
+			}
+			hosts := append([]string(nil), transport.hosts...)
+			sort.Strings(hosts)
+			if want := []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.4:8080"}; !equalStrings(hosts, want) {
+				t.Errorf("requested bridges = %v, want %v", hosts, want)
+			}
+			if reduction != tt.reduction {
+				t.Errorf("reduction = %q, want %q", reduction, tt.reduction)
+			}
+			got := sampleValues(samples)
+			sort.Float64s(got)
+			if !equalValues(got, tt.want) {
+				t.Errorf("samples = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColibriProviderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		pods     []corev1.Pod
+		wantErr  error
+	}{
+		{name: "no selector", pods: []corev1.Pod{bridgePod("jvb-1", "10.0.0.1", corev1.PodRunning)}, wantErr: errSelectorNotExist},
+		{name: "no running bridge", selector: "jitsi-jvb", pods: []corev1.Pod{bridgePod("jvb-1", "", corev1.PodPending)},
+			wantErr: errNoMetricData},
+		{name: "all bridges failed", selector: "jitsi-jvb", pods: []corev1.Pod{bridgePod("jvb-1", "10.0.0.1", corev1.PodRunning)},
+			wantErr: errColibriResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _, _ := newTestColibriProvider(t, tt.selector, tt.pods, map[string]string{})
+			_, _, err := provider.Samples(context.Background(), v1alpha1.Metric{Name: v1alpha1.ResourceConference})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Samples() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
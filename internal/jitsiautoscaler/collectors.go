@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const metricsSubsystem = "meeting_autoscaler"
+
+var (
+	desiredReplicasGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metricsSubsystem + "_desired_replicas",
+		Help: "Desired replica count of the scale target calculated by the autoscaler.",
+	}, []string{"namespace", "name"})
+	currentMetricValueGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metricsSubsystem + "_current_metric_value",
+		Help: "Current value of the autoscaler metric.",
+	}, []string{"namespace", "name", "metric", "index"})
+	targetValueGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metricsSubsystem + "_target_value",
+		Help: "Target value of the autoscaler metric.",
+	}, []string{"namespace", "name", "metric", "index"})
+	queryDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    metricsSubsystem + "_query_duration_seconds",
+		Help:    "Duration of the metric queries to the monitoring system.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"namespace", "name"})
+	queryErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsSubsystem + "_query_errors_total",
+		Help: "Number of failed metric queries to the monitoring system.",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		desiredReplicasGauge,
+		currentMetricValueGauge,
+		targetValueGauge,
+		queryDurationHistogram,
+		queryErrorsCounter,
+	)
+}
+
+func observeQuery(jas *v1alpha1.AutoScaler, start time.Time, err error) {
+	queryDurationHistogram.WithLabelValues(jas.Namespace, jas.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		queryErrorsCounter.WithLabelValues(jas.Namespace, jas.Name).Inc()
+	}
+}
+
+// observeDecision records the values of the metrics, the series of a metric are labelled with its index
+// in the metrics, so several custom metrics don't overwrite each other.
+func observeDecision(jas *v1alpha1.AutoScaler, values []metricValue) {
+	desiredReplicasGauge.WithLabelValues(jas.Namespace, jas.Name).Set(float64(jas.Status.DesiredReplicas))
+	labels := prometheus.Labels{"namespace": jas.Namespace, "name": jas.Name}
+	currentMetricValueGauge.DeletePartialMatch(labels)
+	targetValueGauge.DeletePartialMatch(labels)
+	for i, v := range values {
+		if v.err != nil {
+			continue
+		}
+		index := strconv.Itoa(i)
+		currentMetricValueGauge.WithLabelValues(jas.Namespace, jas.Name, string(v.metric.Name), index).Set(v.value)
+		targetValueGauge.WithLabelValues(jas.Namespace, jas.Name, string(v.metric.Name), index).Set(targetOf(v.metric))
+	}
+}
+
+// forgetAutoScaler drops the series of a deleted autoscaler.
+func forgetAutoScaler(name types.NamespacedName) {
+	labels := prometheus.Labels{"namespace": name.Namespace, "name": name.Name}
+	desiredReplicasGauge.DeletePartialMatch(labels)
+	currentMetricValueGauge.DeletePartialMatch(labels)
+	targetValueGauge.DeletePartialMatch(labels)
+	queryDurationHistogram.DeletePartialMatch(labels)
+	queryErrorsCounter.DeletePartialMatch(labels)
+}
@@ -10,35 +10,50 @@ import (
 	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
 	meetingerr "github.com/onmetal/meeting-operator/internal/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 type Reconciler struct {
 	client.Client
 
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.AutoScaler{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
 		Complete(r)
 }
 
 // +kubebuilder:rbac:groups=meeting.ko,resources=autoscalers,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=meeting.ko,resources=autoscalers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jitsi.meeting.ko,resources=jvbs/scale;jibris/scale;jigasis/scale;webs/scale;prosodies/scale;jicofoes/scale,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments/scale;statefulsets/scale,verbs=get;update;patch
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	reqLogger := r.Log.WithValues("jitsi autoscaler", req.NamespacedName)
-	jas, err := newInstance(ctx, r.Client, reqLogger, req)
+	jas, err := newInstance(ctx, r.Client, reqLogger, r.Recorder, req)
 	if err != nil {
 		if meetingerr.IsNotExist(err) {
+			forgetAutoScaler(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 	jas.Scale()
+	if err := jas.UpdateStatus(); err != nil {
+		reqLogger.Info("can't update autoscaler status", "error", err)
+		return ctrl.Result{}, err
+	}
 	reqLogger.Info("reconciliation finished")
 	return ctrl.Result{RequeueAfter: jas.Repeat()}, nil
 }
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// credentials are the resolved values of the AutoScaler auth section.
+type credentials struct {
+	login, password, token string
+	headers                map[string]string
+}
+
+func resolveCredentials(ctx context.Context, c client.Client, jas *v1alpha1.AutoScaler) (credentials, error) {
+	auth := jas.Spec.Auth
+	creds := credentials{login: auth.Login, password: auth.Password, token: auth.Token, headers: map[string]string{}}
+	for _, ref := range []struct {
+		selector *corev1.SecretKeySelector
+		value    *string
+	}{
+		{auth.LoginSecretRef, &creds.login},
+		{auth.PasswordSecretRef, &creds.password},
+		{auth.TokenSecretRef, &creds.token},
+	} {
+		if ref.selector == nil {
+			continue
+		}
+		value, err := secretValue(ctx, c, jas.Namespace, ref.selector)
+		if err != nil {
+			return credentials{}, err
+		}
+		*ref.value = value
+	}
+	for _, header := range auth.Headers {
+		value := header.Value
+		if header.ValueFrom != nil {
+			var err error
+			if value, err = secretValue(ctx, c, jas.Namespace, header.ValueFrom); err != nil {
+				return credentials{}, err
+			}
+		}
+		creds.headers[header.Name] = value
+	}
+	return creds, nil
+}
+
+func secretValue(ctx context.Context, c client.Client, namespace string, selector *corev1.SecretKeySelector) (string, error) {
+	optional := selector.Optional != nil && *selector.Optional
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, secret); err != nil {
+		if apierrors.IsNotFound(err) && optional {
+			return "", nil
+		}
+		return "", err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		if optional {
+			return "", nil
+		}
+		return "", fmt.Errorf("%w: %s/%s", errSecretKeyNotExist, selector.Name, selector.Key)
+	}
+	return string(value), nil
+}
+
+// authRoundTripper adds the custom headers and, unless the request is already authorized,
+// the bearer token or basic auth credentials to every request.
+type authRoundTripper struct {
+	next   http.RoundTripper
+	bearer bool
+	creds  credentials
+}
+
+func newAuthRoundTripper(next http.RoundTripper, creds credentials, bearer bool) http.RoundTripper {
+	return &authRoundTripper{next: next, creds: creds, bearer: bearer}
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range rt.creds.headers {
+		req.Header.Set(name, value)
+	}
+	if req.Header.Get("Authorization") == "" {
+		switch {
+		case rt.bearer && rt.creds.token != "":
+			req.Header.Set("Authorization", "Bearer "+rt.creds.token)
+		case rt.creds.login != "":
+			req.SetBasicAuth(rt.creds.login, rt.creds.password)
+		}
+	}
+	return rt.next.RoundTrip(req)
+}
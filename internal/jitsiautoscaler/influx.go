@@ -4,109 +4,175 @@
 package jitsiautoscaler
 
 import (
+	"context"
 	"fmt"
-	"math"
+	"net/http"
 	"time"
 
+	"github.com/go-logr/logr"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	influxdb2api "github.com/influxdata/influxdb-client-go/v2/api"
 	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
 )
 
 const (
-	influxQuery               = `from(bucket: "%s")|> range(start: -15m) |>filter(fn: (r) => r["_measurement"] == "jitsi_stats")|> filter(fn: (r) => r["_field"] == "%s")|> distinct(column: "_value")` //nolint:lll //reason: would be removed
+	influxQuery               = `from(bucket: "%s")|> range(start: -%s) |>filter(fn: (r) => r["_measurement"] == "%s")|> filter(fn: (r) => r["_field"] == "%s")|> distinct(column: "_value")` //nolint:lll //reason: would be removed
 	influxCPUMetrics          = "cpu"
 	influxConferencesMetrics  = "conferences"
 	influxParticipantsMetrics = "participants"
+	influxStressLevelMetrics  = "stress_level"
+	influxDownloadMetrics     = "bit_rate_download"
+	influxUploadMetrics       = "bit_rate_upload"
+	influxRequestTimeout      = 20 * time.Second
 )
 
-func (i *influx) Scale() {
-	target := float64(i.Spec.Metric.TargetAverageUtilization)
-	avg := i.getAvgValueForMetric(i.Spec.Metric.Name)
-	if target > avg {
-		desiredReplicas := int32(math.RoundToEven(avg / target))
-		if err := i.scaleDown(desiredReplicas); err != nil {
-			i.log.Info("can't scale down", "error", err)
+// influxSeriesQuery returns the per minute means of the field between two RFC3339 timestamps.
+const influxSeriesQuery = `from(bucket: "%s")|> range(start: %s, stop: %s) |>filter(fn: (r) => r["_measurement"] == "%s")|> filter(fn: (r) => r["_field"] == "%s")|> aggregateWindow(every: 1m, fn: mean, createEmpty: false)` //nolint:lll //reason: flux query
+
+const (
+	influxOrgAnnotation      = "jas.influxdb/org"
+	influxBucketAnnotation   = "jas.influxdb/bucket"
+	influxTokenAnnotation    = "jas.influxdb/token"
+	defaultInfluxOrg         = "influxdata"
+	defaultInfluxBucket      = "jitsi"
+	defaultInfluxMeasurement = "jitsi_stats"
+	defaultInfluxRange       = "15m"
+)
+
+// influxDBSpec converts the deprecated annotations into the typed spec, fields set in the spec take precedence.
+func influxDBSpec(jas *v1alpha1.AutoScaler) v1alpha1.InfluxDB {
+	spec := v1alpha1.InfluxDB{}
+	if jas.Spec.InfluxDB != nil {
+		spec = *jas.Spec.InfluxDB.DeepCopy()
+	}
+	spec.Org = firstNonEmpty(spec.Org, jas.Annotations[influxOrgAnnotation], defaultInfluxOrg)
+	spec.Bucket = firstNonEmpty(spec.Bucket, jas.Annotations[influxBucketAnnotation], defaultInfluxBucket)
+	spec.Measurement = firstNonEmpty(spec.Measurement, defaultInfluxMeasurement)
+	spec.Range = firstNonEmpty(spec.Range, defaultInfluxRange)
+	fields := map[v1alpha1.MetricName]string{
+		v1alpha1.ResourceCPU:             influxCPUMetrics,
+		v1alpha1.ResourceConference:      influxConferencesMetrics,
+		v1alpha1.ResourceParticipants:    influxParticipantsMetrics,
+		v1alpha1.ResourceStressLevel:     influxStressLevelMetrics,
+		v1alpha1.ResourceBitRateDownload: influxDownloadMetrics,
+		v1alpha1.ResourceBitRateUpload:   influxUploadMetrics,
+	}
+	for name, field := range spec.Fields {
+		fields[name] = field
+	}
+	spec.Fields = fields
+	return spec
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
 		}
-		return
 	}
-	desiredReplicas := int32(math.RoundToEven(avg / target))
-	if err := i.scaleUp(desiredReplicas); err != nil {
-		i.log.Info("can't scale up", "error", err)
+	return ""
+}
+
+// influx queries the jitsi metrics with Flux.
+type influx struct {
+	log     logr.Logger
+	iclient influxdb2.Client
+	spec    v1alpha1.InfluxDB
+}
+
+func newInfluxProvider(cfg providerConfig) (MetricsProvider, error) {
+	token := cfg.creds.token
+	if token == "" {
+		var ok bool
+		if token, ok = cfg.jas.Annotations[influxTokenAnnotation]; !ok {
+			return nil, errTokenNotExist
+		}
+		cfg.log.Info("influx token is taken from the deprecated `jas.influxdb/token` annotation, use spec.auth.tokenSecretRef instead")
 	}
+	// influxdb authorizes every request with the token, basic auth credentials are ignored
+	if cfg.creds.login != "" {
+		cfg.log.Info("influx doesn't support basic auth, only the token is used")
+		cfg.creds.login, cfg.creds.password = "", ""
+	}
+	httpClient := &http.Client{
+		Transport: newAuthRoundTripper(cfg.transport, cfg.creds, false),
+		Timeout:   influxRequestTimeout,
+	}
+	return &influx{
+		log:     cfg.log,
+		iclient: influxdb2.NewClientWithOptions(cfg.jas.Spec.Host, token, influxdb2.DefaultOptions().SetHTTPClient(httpClient)),
+		spec:    influxDBSpec(cfg.jas),
+	}, nil
 }
 
-func (i *influx) getAvgValueForMetric(name v1alpha1.MetricName) float64 {
-	switch name {
-	case v1alpha1.ResourceCPU:
-		return i.countAvgValueByRequest(influxCPUMetrics)
-	case v1alpha1.ResourceConference:
-		return i.countAvgValueByRequest(influxConferencesMetrics)
-	case v1alpha1.ResourceParticipants:
-		return i.countAvgValueByRequest(influxParticipantsMetrics)
-	default:
-		return 0
+// Samples returns the values of all records returned by the flux query.
+func (i *influx) Samples(ctx context.Context, metric v1alpha1.Metric) ([]sample, v1alpha1.Reduction, error) {
+	if metric.Name == v1alpha1.ResourceCustom {
+		if metric.Custom == nil {
+			return nil, "", errCustomQueryNotSet
+		}
+		samples, err := i.querySamples(ctx, metric.Custom.Query)
+		return samples, metric.Custom.Reduction, err
 	}
+	field, ok := i.spec.Fields[metric.Name]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", errUnknownMetric, metric.Name)
+	}
+	query := fmt.Sprintf(influxQuery, i.spec.Bucket, i.spec.Range, i.spec.Measurement, field)
+	samples, err := i.querySamples(ctx, query)
+	return samples, v1alpha1.ReductionAvg, err
 }
 
-func (i *influx) countAvgValueByRequest(field string) float64 {
-	var sum, count, value float64
-	var ok bool
-	query := fmt.Sprintf(influxQuery, i.bucket, field)
-	result, err := i.iclient.QueryAPI(i.org).Query(i.ctx, query)
+// Series reduces the windowed means of all series per timestamp. Custom flux queries define
+// their own range, so they can't be forecasted.
+func (i *influx) Series(ctx context.Context, metric v1alpha1.Metric, start, end time.Time) ([]sample, error) {
+	if metric.Name == v1alpha1.ResourceCustom {
+		return nil, fmt.Errorf("%w: %s", errPredictionNotSupported, metric.Name)
+	}
+	field, ok := i.spec.Fields[metric.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownMetric, metric.Name)
+	}
+	query := fmt.Sprintf(influxSeriesQuery, i.spec.Bucket, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339),
+		i.spec.Measurement, field)
+	samples, err := i.querySamples(ctx, query)
 	if err != nil {
-		i.log.Info("can't query influx database", "error", err)
-		return 0
+		return nil, err
+	}
+	return reduceByTimestamp(samples, v1alpha1.ReductionAvg), nil
+}
+
+func (i *influx) querySamples(ctx context.Context, query string) ([]sample, error) {
+	result, err := i.iclient.QueryAPI(i.spec.Org).Query(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 	defer func(result *influxdb2api.QueryTableResult) {
 		if closeErr := result.Close(); closeErr != nil {
-			i.log.Info("can't close connection to influx properly", "error", err)
+			i.log.Info("can't close connection to influx properly", "error", closeErr)
 		}
 	}(result)
+	var samples []sample
 	for result.Next() {
-		values := result.Record().Values()
-		value, ok = values["_value"].(float64)
+		record := result.Record()
+		value, ok := toFloat(record.Value())
 		if !ok {
-			count++
 			continue
 		}
-		sum = +value
-		count++
-	}
-	return sum / count
-}
-
-func (i *influx) scaleUp(desiredReplicas int32) error {
-	jitsi, getErr := getJVBCR(i.ctx, i.Client, i.Spec.ScaleTargetRef.Name, i.Namespace)
-	if getErr != nil {
-		return getErr
-	}
-	jitsi.Spec.Replicas *= desiredReplicas
-	if jitsi.Spec.Replicas > i.Spec.MaxReplicas {
-		jitsi.Spec.Replicas = i.Spec.MaxReplicas
+		samples = append(samples, sample{timestamp: record.Time(), value: value})
 	}
-	return i.Update(i.ctx, jitsi)
+	return samples, result.Err()
 }
 
-func (i *influx) scaleDown(desiredReplicas int32) error {
-	jitsi, getErr := getJVBCR(i.ctx, i.Client, i.Spec.ScaleTargetRef.Name, i.Namespace)
-	if getErr != nil {
-		return getErr
-	}
-	jitsi.Spec.Replicas = desiredReplicas
-	if jitsi.Spec.Replicas < i.Spec.MinReplicas {
-		jitsi.Spec.Replicas = i.Spec.MinReplicas
-	}
-	return i.Update(i.ctx, jitsi)
-}
-
-func (i *influx) Repeat() time.Duration {
-	if i.Spec.Interval == "" {
-		return defaultRepeatInterval
-	}
-	interval, err := time.ParseDuration(i.Spec.Interval)
-	if err != nil {
-		i.log.Info("can't parse duration", "error", err)
-		return defaultRepeatInterval
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
 	}
-	return interval
 }
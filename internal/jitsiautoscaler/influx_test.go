@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const influxCSVResponse = `#datatype,string,long,dateTime:RFC3339,double
+#group,false,false,false,false
+#default,_result,,,
+,result,table,_time,_value
+,,0,2023-11-14T22:13:20Z,1.5
+,,1,2023-11-14T22:13:20Z,2.5
+,,1,2023-11-14T22:14:20Z,4
+
+`
+
+// influxRequest is what the fake Flux API received.
+type influxRequest struct {
+	path, org string
+	header    http.Header
+	query     string
+	queryType string
+}
+
+func newInfluxServer(t *testing.T, status int, body string) (*httptest.Server, *influxRequest) {
+	t.Helper()
+	received := &influxRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.path, received.org, received.header = r.URL.Path, r.URL.Query().Get("org"), r.Header.Clone()
+		var payload struct {
+			Query string `json:"query"`
+			Type  string `json:"type"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("can't decode flux request: %v", err)
+		}
+		received.query, received.queryType = payload.Query, payload.Type
+		if status == http.StatusOK {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func newTestInfluxProvider(t *testing.T, jas *v1alpha1.AutoScaler, creds credentials) MetricsProvider {
+	t.Helper()
+	if creds.headers == nil {
+		creds.headers = map[string]string{}
+	}
+	provider, err := newInfluxProvider(providerConfig{jas: jas, log: logr.Discard(), creds: creds, transport: http.DefaultTransport})
+	if err != nil {
+		t.Fatalf("can't create provider: %v", err)
+	}
+	return provider
+}
+
+func TestInfluxProviderRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		spec        *v1alpha1.InfluxDB
+		creds       credentials
+		metric      v1alpha1.Metric
+		wantOrg     string
+		wantQuery   string
+		wantHeaders map[string]string
+	}{
+		{
+			name:        "defaults with token",
+			creds:       credentials{token: "secret"},
+			metric:      v1alpha1.Metric{Name: v1alpha1.ResourceConference},
+			wantOrg:     defaultInfluxOrg,
+			wantQuery:   fmt.Sprintf(influxQuery, defaultInfluxBucket, defaultInfluxRange, defaultInfluxMeasurement, influxConferencesMetrics),
+			wantHeaders: map[string]string{"Authorization": "Token secret"},
+		},
+		{
+			name:        "deprecated annotations",
+			annotations: map[string]string{influxOrgAnnotation: "org", influxBucketAnnotation: "bucket", influxTokenAnnotation: "annotated"},
+			metric:      v1alpha1.Metric{Name: v1alpha1.ResourceStressLevel},
+			wantOrg:     "org",
+			wantQuery:   fmt.Sprintf(influxQuery, "bucket", defaultInfluxRange, defaultInfluxMeasurement, influxStressLevelMetrics),
+			wantHeaders: map[string]string{"Authorization": "Token annotated"},
+		},
+		{
+			name:        "spec takes precedence over annotations",
+			annotations: map[string]string{influxOrgAnnotation: "org", influxTokenAnnotation: "annotated"},
+			spec: &v1alpha1.InfluxDB{Org: "spec-org", Bucket: "spec-bucket", Measurement: "bridges", Range: "5m",
+				Fields: map[v1alpha1.MetricName]string{v1alpha1.ResourceParticipants: "endpoints"}},
+			creds:       credentials{token: "secret", headers: map[string]string{"X-Tenant": "team"}},
+			metric:      v1alpha1.Metric{Name: v1alpha1.ResourceParticipants},
+			wantOrg:     "spec-org",
+			wantQuery:   fmt.Sprintf(influxQuery, "spec-bucket", "5m", "bridges", "endpoints"),
+			wantHeaders: map[string]string{"Authorization": "Token secret", "X-Tenant": "team"},
+		},
+		{
+			name:        "custom flux query",
+			creds:       credentials{token: "secret"},
+			metric:      v1alpha1.Metric{Name: v1alpha1.ResourceCustom, Custom: &v1alpha1.CustomMetric{Query: `from(bucket: "jitsi")`}},
+			wantOrg:     defaultInfluxOrg,
+			wantQuery:   `from(bucket: "jitsi")`,
+			wantHeaders: map[string]string{"Authorization": "Token secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newInfluxServer(t, http.StatusOK, influxCSVResponse)
+			jas := &v1alpha1.AutoScaler{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       v1alpha1.AutoScalerSpec{MonitoringType: v1alpha1.MonitoringInfluxDB, Host: server.URL, InfluxDB: tt.spec},
+			}
+			provider := newTestInfluxProvider(t, jas, tt.creds)
+			if _, _, err := provider.Samples(context.Background(), tt.metric); err != nil {
+				t.Fatalf("Samples() error = %v", err)
+			}
+			if received.path != "/api/v2/query" {
+				t.Errorf("path = %q, want %q", received.path, "/api/v2/query")
+			}
+			if received.org != tt.wantOrg {
+				t.Errorf("org = %q, want %q", received.org, tt.wantOrg)
+			}
+			if received.queryType != "flux" {
+				t.Errorf("query type = %q, want flux", received.queryType)
+			}
+			if received.query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", received.query, tt.wantQuery)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := received.header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInfluxProviderWithoutToken(t *testing.T) {
+	jas := &v1alpha1.AutoScaler{Spec: v1alpha1.AutoScalerSpec{MonitoringType: v1alpha1.MonitoringInfluxDB}}
+	_, err := newInfluxProvider(providerConfig{jas: jas, log: logr.Discard(), creds: credentials{}, transport: http.DefaultTransport})
+	if !errors.Is(err, errTokenNotExist) {
+		t.Errorf("newInfluxProvider() error = %v, want %v", err, errTokenNotExist)
+	}
+}
+
+func TestInfluxProviderSamples(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    []float64
+		wantErr string
+	}{
+		{name: "all records", status: http.StatusOK, body: influxCSVResponse, want: []float64{1.5, 2.5, 4}},
+		{name: "empty result", status: http.StatusOK, body: "", want: []float64{}},
+		{
+			name:    "bad query",
+			status:  http.StatusBadRequest,
+			body:    `{"code":"invalid","message":"compilation failed: undefined identifier"}`,
+			wantErr: "compilation failed: undefined identifier",
+		},
+		{
+			name:    "unauthorized",
+			status:  http.StatusUnauthorized,
+			body:    `{"code":"unauthorized","message":"unauthorized access"}`,
+			wantErr: "unauthorized access",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newInfluxServer(t, tt.status, tt.body)
+			jas := &v1alpha1.AutoScaler{Spec: v1alpha1.AutoScalerSpec{MonitoringType: v1alpha1.MonitoringInfluxDB, Host: server.URL}}
+			provider := newTestInfluxProvider(t, jas, credentials{token: "secret"})
+			samples, reduction, err := provider.Samples(context.Background(), v1alpha1.Metric{Name: v1alpha1.ResourceConference})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Samples() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Samples() error = %v", err)
+			}
+			if reduction != v1alpha1.ReductionAvg {
+				t.Errorf("reduction = %q, want %q", reduction, v1alpha1.ReductionAvg)
+			}
+			if got := sampleValues(samples); !equalValues(got, tt.want) {
+				t.Errorf("samples = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfluxProviderSeries(t *testing.T) {
+	server, received := newInfluxServer(t, http.StatusOK, influxCSVResponse)
+	jas := &v1alpha1.AutoScaler{Spec: v1alpha1.AutoScalerSpec{MonitoringType: v1alpha1.MonitoringInfluxDB, Host: server.URL}}
+	provider := newTestInfluxProvider(t, jas, credentials{token: "secret"})
+	start, end := time.Unix(1700000000, 0), time.Unix(1700000060, 0)
+	series, err := provider.Series(context.Background(), v1alpha1.Metric{Name: v1alpha1.ResourceConference}, start, end)
+	if err != nil {
+		t.Fatalf("Series() error = %v", err)
+	}
+	wantQuery := fmt.Sprintf(influxSeriesQuery, defaultInfluxBucket, "2023-11-14T22:13:20Z", "2023-11-14T22:14:20Z",
+		defaultInfluxMeasurement, influxConferencesMetrics)
+	if received.query != wantQuery {
+		t.Errorf("query = %q, want %q", received.query, wantQuery)
+	}
+	// the means of all series are averaged per timestamp
+	if got, want := sampleValues(series), []float64{2, 4}; !equalValues(got, want) {
+		t.Errorf("series = %v, want %v", got, want)
+	}
+}
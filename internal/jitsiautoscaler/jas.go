@@ -6,53 +6,83 @@ package jitsiautoscaler
 import (
 	"context"
 	"errors"
+	"net/http"
 	"time"
 
 	"github.com/go-logr/logr"
-	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
-	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
 	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
 	meetingerr "github.com/onmetal/meeting-operator/internal/errors"
-	promapi "github.com/prometheus/client_golang/api"
-	promv1api "github.com/prometheus/client_golang/api/prometheus/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const defaultRepeatInterval = 600 * time.Second
+const (
+	defaultRepeatInterval = 600 * time.Second
+	queryTimeout          = 30 * time.Second
+)
 
-var errTokenNotExist = errors.New("token not exist")
+var (
+	errTokenNotExist        = errors.New("token not exist")
+	errUnknownMetric        = errors.New("unknown metric")
+	errNoMetricData         = errors.New("no data returned for metric")
+	errNotEnoughSamples     = errors.New("not enough valid samples for metric")
+	errInvalidMetricValue   = errors.New("metric value is not a finite number")
+	errNoMetrics            = errors.New("no metrics configured")
+	errCustomQueryNotSet    = errors.New("custom metric requires a query")
+	errSecretKeyNotExist    = errors.New("secret key not exist")
+	errConfigMapKeyNotExist = errors.New("config map key not exist")
+	errInvalidCA            = errors.New("no valid CA certificate provided")
+)
 
 type AutoScaler interface {
 	Scale()
+	UpdateStatus() error
 	Repeat() time.Duration
 }
 
-type prom struct {
-	client.Client
-	*v1alpha1.AutoScaler
+// MetricsProvider queries the metrics of the autoscaler from a monitoring system.
+type MetricsProvider interface {
+	// Samples returns the latest samples of the metric, e.g. one per bridge, and how they are reduced
+	// to the metric value.
+	Samples(ctx context.Context, metric v1alpha1.Metric) ([]sample, v1alpha1.Reduction, error)
+	// Series returns the samples of the metric between start and end, reduced to one sample per timestamp.
+	Series(ctx context.Context, metric v1alpha1.Metric, start, end time.Time) ([]sample, error)
+}
 
-	ctx       context.Context
+// providerConfig is everything a metrics provider needs to connect to the monitoring system.
+type providerConfig struct {
+	client    client.Client
+	jas       *v1alpha1.AutoScaler
 	log       logr.Logger
-	apiv1     promv1api.API
-	timeRange promv1api.Range
+	creds     credentials
+	transport http.RoundTripper
+}
+
+type providerFactory func(cfg providerConfig) (MetricsProvider, error)
+
+// providers maps the monitoring types to the constructors of their metrics providers.
+var providers = map[string]providerFactory{
+	v1alpha1.MonitoringPrometheus:      newPromProvider,
+	v1alpha1.MonitoringVictoriaMetrics: newPromProvider,
+	v1alpha1.MonitoringThanos:          newPromProvider,
+	v1alpha1.MonitoringInfluxDB:        newInfluxProvider,
+	v1alpha1.MonitoringColibri:         newColibriProvider,
 }
 
-type influx struct {
+type autoscaler struct {
 	client.Client
 	*v1alpha1.AutoScaler
 
-	ctx         context.Context
-	log         logr.Logger
-	iclient     influxdb2.Client
-	org, bucket string
+	ctx        context.Context
+	log        logr.Logger
+	recorder   record.EventRecorder
+	provider   MetricsProvider
+	calculator ReplicaCalculator
 }
 
-func newInstance(ctx context.Context, c client.Client, l logr.Logger, req ctrl.Request) (AutoScaler, error) {
-	var p *prom
-	var influxdb *influx
+func newInstance(ctx context.Context, c client.Client, l logr.Logger, recorder record.EventRecorder, req ctrl.Request) (AutoScaler, error) {
 	jas := &v1alpha1.AutoScaler{}
 	if err := c.Get(ctx, req.NamespacedName, jas); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -60,70 +90,63 @@ func newInstance(ctx context.Context, c client.Client, l logr.Logger, req ctrl.R
 		}
 		return nil, err
 	}
-	switch jas.Spec.MonitoringType {
-	case "prometheus":
-		promClient, err := promapi.NewClient(promapi.Config{
-			Address: jas.Spec.Host,
-		})
+	newProvider, ok := providers[jas.Spec.MonitoringType]
+	if !ok {
+		return nil, meetingerr.NotExist(jas.Spec.MonitoringType)
+	}
+	creds, err := resolveCredentials(ctx, c, jas)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(ctx, c, jas)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := newProvider(providerConfig{client: c, jas: jas, log: l, creds: creds, transport: transport})
+	if err != nil {
+		return nil, err
+	}
+	return &autoscaler{
+		Client:     c,
+		AutoScaler: jas,
+		ctx:        ctx,
+		log:        l,
+		recorder:   recorder,
+		provider:   provider,
+		calculator: newReplicaCalculator(),
+	}, nil
+}
+
+func (a *autoscaler) Scale() {
+	ctx, cancel := context.WithTimeout(a.ctx, queryTimeout)
+	defer cancel()
+	values := queryMetrics(a.AutoScaler, a.log, func(metric v1alpha1.Metric) (float64, error) {
+		start := time.Now()
+		samples, reduction, err := a.provider.Samples(ctx, metric)
+		observeQuery(a.AutoScaler, start, err)
 		if err != nil {
-			return nil, err
-		}
-		timeRange := promv1api.Range{
-			Start: time.Now().Add(-promRangeStart),
-			End:   time.Now(),
-			Step:  promRangeStep,
-		}
-		v1api := promv1api.NewAPI(promClient)
-		p = &prom{
-			Client:     c,
-			AutoScaler: jas,
-			ctx:        ctx,
-			log:        l,
-			apiv1:      v1api,
-			timeRange:  timeRange,
-		}
-		return p, nil
-	case "influxdb":
-		var org, bucket string
-		var ok bool
-		org, ok = jas.Annotations["jas.influxdb/org"]
-		if !ok {
-			l.Info("influx org not provided, setting default to `influxdata`")
-			org = "influxdata"
+			return 0, err
 		}
-		bucket, ok = jas.Annotations["jas.influxdb/bucket"]
-		if !ok {
-			l.Info("influx bucket not provided, setting default to `jitsi`")
-			bucket = "jitsi"
+		return aggregate(samples, reduction, a.Spec.Aggregation, time.Now())
+	})
+	applyPredictions(a.AutoScaler, a.log, values, func(metric v1alpha1.Metric, start, end time.Time) ([]sample, error) {
+		queryStart := time.Now()
+		series, err := a.provider.Series(ctx, metric, start, end)
+		if !errors.Is(err, errPredictionNotSupported) {
+			observeQuery(a.AutoScaler, queryStart, err)
 		}
-		token, ok := jas.Annotations["jas.influxdb/token"]
-		if !ok {
-			return nil, errTokenNotExist
-		}
-		influxClient := influxdb2.NewClient(jas.Spec.Host, token)
-		influxdb = &influx{
-			Client:     c,
-			AutoScaler: jas,
-			ctx:        ctx,
-			log:        l,
-			iclient:    influxClient,
-			org:        org,
-			bucket:     bucket,
-		}
-		return influxdb, nil
-	default:
-		return nil, meetingerr.NotExist(jas.Spec.MonitoringType)
+		return series, err
+	}, time.Now())
+	if err := a.scale(ctx, values); err != nil {
+		a.log.Info("can't scale", "error", err)
 	}
+	observeDecision(a.AutoScaler, values)
 }
 
-func getJVBCR(ctx context.Context, c client.Client, name, namespace string) (*v1beta1.JVB, error) {
-	jitsi := &v1beta1.JVB{}
-	keyObj := ctrl.Request{NamespacedName: types.NamespacedName{
-		Namespace: namespace,
-		Name:      name,
-	}}
-	if getErr := c.Get(ctx, keyObj.NamespacedName, jitsi); getErr != nil {
-		return nil, getErr
-	}
-	return jitsi, nil
+func (a *autoscaler) UpdateStatus() error {
+	return updateStatus(a.ctx, a.Client, a.AutoScaler)
+}
+
+func (a *autoscaler) Repeat() time.Duration {
+	return repeatAfter(a.AutoScaler, a.log, time.Now())
 }
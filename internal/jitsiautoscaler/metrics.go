@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// metricValue is the observed value of a single autoscaler metric.
+type metricValue struct {
+	metric v1alpha1.Metric
+	value  float64
+	err    error
+	// predicted is the forecast including the headroom, used instead of value if it is higher.
+	predicted     float64
+	hasPrediction bool
+}
+
+// decisionValue is the value the replica recommendation is calculated from.
+func (v metricValue) decisionValue() float64 {
+	if v.hasPrediction {
+		return max(v.value, v.predicted)
+	}
+	return v.value
+}
+
+// metricsOf returns the metrics of the autoscaler, falling back to the deprecated single metric.
+func metricsOf(jas *v1alpha1.AutoScaler) []v1alpha1.Metric {
+	if len(jas.Spec.Metrics) != 0 {
+		return jas.Spec.Metrics
+	}
+	if jas.Spec.Metric.Name == "" {
+		return nil
+	}
+	return []v1alpha1.Metric{jas.Spec.Metric}
+}
+
+func queryMetrics(jas *v1alpha1.AutoScaler, l logr.Logger, query func(v1alpha1.Metric) (float64, error)) []metricValue {
+	metrics := metricsOf(jas)
+	values := make([]metricValue, 0, len(metrics))
+	for _, metric := range metrics {
+		value, err := query(metric)
+		if err != nil {
+			l.Info("can't get metric value", "metric", metric.Name, "error", err)
+		}
+		values = append(values, metricValue{metric: metric, value: value, err: err})
+	}
+	return values
+}
+
+// recommendReplicas returns the highest replica recommendation across all metrics and records
+// every metric in the status. Scale down is not allowed while some of the metrics are unavailable.
+func recommendReplicas(jas *v1alpha1.AutoScaler, calc ReplicaCalculator, currentReplicas int32, values []metricValue) (int32, error) {
+	statuses := make([]v1alpha1.MetricStatus, 0, len(values))
+	errs := make([]error, 0, len(values))
+	var recommendation int32
+	var recommendationValue *resource.Quantity
+	for _, v := range values {
+		metricStatus := v1alpha1.MetricStatus{Name: v.metric.Name}
+		if v.err != nil {
+			metricStatus.Error = v.err.Error()
+			statuses = append(statuses, metricStatus)
+			errs = append(errs, v.err)
+			continue
+		}
+		metricStatus.CurrentValue = toQuantity(v.value)
+		metricStatus.DesiredReplicas = calc.DesiredReplicas(currentReplicas, v.decisionValue(), targetOf(v.metric))
+		if recommendationValue == nil || metricStatus.DesiredReplicas > recommendation {
+			recommendation = metricStatus.DesiredReplicas
+			recommendationValue = metricStatus.CurrentValue
+		}
+		statuses = append(statuses, metricStatus)
+	}
+	jas.Status.CurrentMetrics = statuses
+	queryErr := errors.Join(errs...)
+	if recommendationValue == nil {
+		if queryErr == nil {
+			queryErr = errNoMetrics
+		}
+		setMetricError(jas, queryErr)
+		return currentReplicas, queryErr
+	}
+	jas.Status.CurrentMetricValue = recommendationValue
+	setMetricsAvailable(jas, queryErr)
+	if queryErr != nil && recommendation < currentReplicas {
+		return currentReplicas, nil
+	}
+	return recommendation, nil
+}
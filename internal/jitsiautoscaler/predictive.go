@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	predictionSeason          = 7 * 24 * time.Hour
+	predictionStep            = time.Minute
+	defaultPredictionLookback = time.Hour
+	defaultPredictionHorizon  = 15 * time.Minute
+)
+
+var errPredictionNotSupported = errors.New("prediction is not supported for this metric")
+
+// seriesQuery returns the samples of the metric between start and end, reduced to one sample per timestamp.
+type seriesQuery func(metric v1alpha1.Metric, start, end time.Time) ([]sample, error)
+
+// applyPredictions forecasts every available metric and records the forecasts in the status.
+// Metrics which can't be forecasted are scaled on their current value.
+func applyPredictions(jas *v1alpha1.AutoScaler, l logr.Logger, values []metricValue, query seriesQuery, now time.Time) {
+	if jas.Spec.Predictive == nil {
+		jas.Status.Predictions = nil
+		return
+	}
+	lookback, horizon := predictionWindows(jas.Spec.Predictive)
+	headroom := 1 + float64(jas.Spec.Predictive.HeadroomPercent)/100
+	predictions := make([]v1alpha1.MetricPrediction, 0, len(values))
+	for i := range values {
+		if values[i].err != nil {
+			continue
+		}
+		prediction := v1alpha1.MetricPrediction{Name: values[i].metric.Name, Time: metav1.NewTime(now.Add(horizon))}
+		forecast, err := predict(&prediction, values[i].metric, query, now, lookback, horizon)
+		if err != nil {
+			l.Info("can't forecast metric", "metric", values[i].metric.Name, "error", err)
+			prediction.Error = err.Error()
+			predictions = append(predictions, prediction)
+			continue
+		}
+		values[i].predicted = forecast * headroom
+		values[i].hasPrediction = true
+		predictions = append(predictions, prediction)
+	}
+	jas.Status.Predictions = predictions
+}
+
+func predictionWindows(spec *v1alpha1.Predictive) (time.Duration, time.Duration) {
+	lookback, horizon := defaultPredictionLookback, defaultPredictionHorizon
+	if spec.Lookback != nil && spec.Lookback.Duration > 0 {
+		lookback = spec.Lookback.Duration
+	}
+	if spec.Horizon != nil && spec.Horizon.Duration > 0 {
+		horizon = spec.Horizon.Duration
+	}
+	return lookback, horizon
+}
+
+// predict extrapolates the linear trend of the lookback window to now+horizon and adds the change
+// the metric had between the same times one week ago. Without data of the last week only the
+// trend is used.
+func predict(prediction *v1alpha1.MetricPrediction, metric v1alpha1.Metric, query seriesQuery,
+	now time.Time, lookback, horizon time.Duration,
+) (float64, error) {
+	recent, err := query(metric, now.Add(-lookback), now)
+	if err != nil {
+		return 0, err
+	}
+	if len(recent) == 0 {
+		return 0, errNoMetricData
+	}
+	at := now.Add(horizon)
+	forecast := linearTrend(recent, at)
+	prediction.TrendValue = toQuantity(forecast)
+	seasonStart := now.Add(-predictionSeason)
+	season, err := query(metric, seasonStart.Add(-predictionStep), seasonStart.Add(horizon))
+	if err == nil && len(season) != 0 {
+		startValue := nearest(season, seasonStart)
+		endValue := nearest(season, at.Add(-predictionSeason))
+		prediction.SeasonalStartValue = toQuantity(startValue)
+		prediction.SeasonalEndValue = toQuantity(endValue)
+		forecast += endValue - startValue
+	}
+	forecast = max(forecast, 0)
+	prediction.ForecastValue = toQuantity(forecast)
+	return forecast, nil
+}
+
+// linearTrend fits a least squares line to the samples and returns its value at the given time.
+func linearTrend(samples []sample, at time.Time) float64 {
+	origin := samples[0].timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.timestamp.Sub(origin).Seconds()
+		sumX += x
+		sumY += s.value
+		sumXY += x * s.value
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return sumY / n
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+	return intercept + slope*at.Sub(origin).Seconds()
+}
+
+// nearest returns the value of the sample closest to the given time.
+func nearest(samples []sample, at time.Time) float64 {
+	result := samples[0]
+	for _, s := range samples[1:] {
+		if s.timestamp.Sub(at).Abs() < result.timestamp.Sub(at).Abs() {
+			result = s
+		}
+	}
+	return result.value
+}
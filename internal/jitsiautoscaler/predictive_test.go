@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var predictionNow = time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+
+// syntheticSeries returns a query which samples the function every predictionStep.
+func syntheticSeries(f func(at time.Time) float64) seriesQuery {
+	return func(_ v1alpha1.Metric, start, end time.Time) ([]sample, error) {
+		var samples []sample
+		for at := start; !at.After(end); at = at.Add(predictionStep) {
+			samples = append(samples, sample{timestamp: at, value: f(at)})
+		}
+		return samples, nil
+	}
+}
+
+// rising grows by one per ten minutes and is 6 at predictionNow.
+func rising(at time.Time) float64 {
+	return 6 + at.Sub(predictionNow).Minutes()/10
+}
+
+// weekAgoRamp is flat now and rose from 10 to 14 during the 15 minutes after the same time one week ago.
+func weekAgoRamp(at time.Time) float64 {
+	seasonStart := predictionNow.Add(-predictionSeason)
+	switch {
+	case at.After(predictionNow.Add(-24 * time.Hour)):
+		return 5
+	case at.Before(seasonStart):
+		return 10
+	case at.After(seasonStart.Add(15 * time.Minute)):
+		return 14
+	default:
+		return 10 + 4*at.Sub(seasonStart).Minutes()/15
+	}
+}
+
+func approximately(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLinearTrend(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []sample
+		at      time.Time
+		want    float64
+	}{
+		{
+			name:    "rising",
+			samples: []sample{{predictionNow.Add(-20 * time.Minute), 4}, {predictionNow.Add(-10 * time.Minute), 5}, {predictionNow, 6}},
+			at:      predictionNow.Add(15 * time.Minute),
+			want:    7.5,
+		},
+		{
+			name:    "falling",
+			samples: []sample{{predictionNow.Add(-10 * time.Minute), 8}, {predictionNow, 6}},
+			at:      predictionNow.Add(10 * time.Minute),
+			want:    4,
+		},
+		{
+			name:    "flat",
+			samples: []sample{{predictionNow.Add(-10 * time.Minute), 3}, {predictionNow, 3}},
+			at:      predictionNow.Add(time.Hour),
+			want:    3,
+		},
+		{
+			name: "least squares fit of noisy samples",
+			samples: []sample{
+				{predictionNow.Add(-30 * time.Minute), 1}, {predictionNow.Add(-20 * time.Minute), 3},
+				{predictionNow.Add(-10 * time.Minute), 3}, {predictionNow, 5},
+			},
+			at:   predictionNow.Add(10 * time.Minute),
+			want: 6,
+		},
+		{
+			name:    "single sample",
+			samples: []sample{{predictionNow, 4}},
+			at:      predictionNow.Add(15 * time.Minute),
+			want:    4,
+		},
+		{
+			name:    "samples at the same time",
+			samples: []sample{{predictionNow, 2}, {predictionNow, 4}},
+			at:      predictionNow.Add(15 * time.Minute),
+			want:    3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linearTrend(tt.samples, tt.at); !approximately(got, tt.want) {
+				t.Errorf("linearTrend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredict(t *testing.T) {
+	tests := []struct {
+		name         string
+		recent       seriesQuery
+		season       seriesQuery
+		want         float64
+		wantTrend    float64
+		wantSeasonal []float64
+		wantErr      error
+	}{
+		{
+			name:      "trend",
+			recent:    syntheticSeries(rising),
+			season:    syntheticSeries(func(time.Time) float64 { return 2 }),
+			want:      7.5,
+			wantTrend: 7.5,
+			// a flat week ago adds nothing
+			wantSeasonal: []float64{2, 2},
+		},
+		{
+			name:         "week-ago seasonal delta",
+			recent:       syntheticSeries(weekAgoRamp),
+			season:       syntheticSeries(weekAgoRamp),
+			want:         9,
+			wantTrend:    5,
+			wantSeasonal: []float64{10, 14},
+		},
+		{
+			name:      "trend only without last week",
+			recent:    syntheticSeries(rising),
+			season:    func(v1alpha1.Metric, time.Time, time.Time) ([]sample, error) { return nil, errNoMetricData },
+			want:      7.5,
+			wantTrend: 7.5,
+		},
+		{
+			name:      "never negative",
+			recent:    syntheticSeries(func(at time.Time) float64 { return 1 - at.Sub(predictionNow).Minutes()/5 }),
+			season:    syntheticSeries(func(time.Time) float64 { return 0 }),
+			want:      0,
+			wantTrend: -2,
+			// the seasonal values are shown as well
+			wantSeasonal: []float64{0, 0},
+		},
+		{
+			name:    "no recent samples",
+			recent:  func(v1alpha1.Metric, time.Time, time.Time) ([]sample, error) { return nil, nil },
+			wantErr: errNoMetricData,
+		},
+		{
+			name:    "recent query fails",
+			recent:  func(v1alpha1.Metric, time.Time, time.Time) ([]sample, error) { return nil, errPredictionNotSupported },
+			wantErr: errPredictionNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := func(metric v1alpha1.Metric, start, end time.Time) ([]sample, error) {
+				if start.Before(predictionNow.Add(-24 * time.Hour)) {
+					return tt.season(metric, start, end)
+				}
+				return tt.recent(metric, start, end)
+			}
+			prediction := &v1alpha1.MetricPrediction{}
+			got, err := predict(prediction, v1alpha1.Metric{Name: v1alpha1.ResourceConference}, query,
+				predictionNow, time.Hour, 15*time.Minute)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("predict() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("predict() error = %v", err)
+			}
+			if !approximately(got, tt.want) {
+				t.Errorf("predict() = %v, want %v", got, tt.want)
+			}
+			if value := prediction.ForecastValue.AsApproximateFloat64(); !approximately(value, tt.want) {
+				t.Errorf("forecast value = %v, want %v", value, tt.want)
+			}
+			if value := prediction.TrendValue.AsApproximateFloat64(); !approximately(value, tt.wantTrend) {
+				t.Errorf("trend value = %v, want %v", value, tt.wantTrend)
+			}
+			if tt.wantSeasonal == nil {
+				if prediction.SeasonalStartValue != nil || prediction.SeasonalEndValue != nil {
+					t.Errorf("seasonal values = %v-%v, want none", prediction.SeasonalStartValue, prediction.SeasonalEndValue)
+				}
+				return
+			}
+			start, end := prediction.SeasonalStartValue.AsApproximateFloat64(), prediction.SeasonalEndValue.AsApproximateFloat64()
+			if !approximately(start, tt.wantSeasonal[0]) || !approximately(end, tt.wantSeasonal[1]) {
+				t.Errorf("seasonal values = %v-%v, want %v", start, end, tt.wantSeasonal)
+			}
+		})
+	}
+}
+
+func TestApplyPredictions(t *testing.T) {
+	jas := &v1alpha1.AutoScaler{Spec: v1alpha1.AutoScalerSpec{Predictive: &v1alpha1.Predictive{
+		Lookback:        &metav1.Duration{Duration: 30 * time.Minute},
+		Horizon:         &metav1.Duration{Duration: 15 * time.Minute},
+		HeadroomPercent: 20,
+	}}}
+	values := []metricValue{
+		{metric: v1alpha1.Metric{Name: v1alpha1.ResourceConference}, value: 6},
+		{metric: v1alpha1.Metric{Name: v1alpha1.ResourceParticipants}, err: errNoMetricData},
+		{metric: v1alpha1.Metric{Name: v1alpha1.ResourceCustom}, value: 3},
+	}
+	query := func(metric v1alpha1.Metric, start, end time.Time) ([]sample, error) {
+		switch {
+		case metric.Name == v1alpha1.ResourceCustom:
+			return nil, errPredictionNotSupported
+		case start.Before(predictionNow.Add(-24 * time.Hour)):
+			// no data of last week, only the trend is used
+			return nil, nil
+		}
+		return syntheticSeries(rising)(metric, start, end)
+	}
+	applyPredictions(jas, logr.Discard(), values, query, predictionNow)
+
+	if !values[0].hasPrediction || !approximately(values[0].predicted, 7.5*1.2) {
+		t.Errorf("predicted = %v, want %v with headroom", values[0].predicted, 7.5*1.2)
+	}
+	if values[1].hasPrediction || values[2].hasPrediction {
+		t.Error("unavailable and unsupported metrics must be scaled on their current value")
+	}
+	if len(jas.Status.Predictions) != 2 {
+		t.Fatalf("predictions = %v, want the available metrics", jas.Status.Predictions)
+	}
+	if got := jas.Status.Predictions[0]; !got.Time.Time.Equal(predictionNow.Add(15*time.Minute)) || got.Error != "" {
+		t.Errorf("prediction = %+v, want a forecast for %v", got, predictionNow.Add(15*time.Minute))
+	}
+	if got := jas.Status.Predictions[1]; got.Name != v1alpha1.ResourceCustom || got.Error == "" {
+		t.Errorf("prediction = %+v, want the error of the custom metric", got)
+	}
+
+	jas.Spec.Predictive = nil
+	applyPredictions(jas, logr.Discard(), values, query, predictionNow)
+	if jas.Status.Predictions != nil {
+		t.Errorf("predictions = %v, want none without predictive mode", jas.Status.Predictions)
+	}
+}
+
+func TestDecisionValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value metricValue
+		want  float64
+	}{
+		{name: "no prediction", value: metricValue{value: 8}, want: 8},
+		{name: "higher forecast", value: metricValue{value: 8, predicted: 12, hasPrediction: true}, want: 12},
+		{name: "never below the reactive value", value: metricValue{value: 8, predicted: 5, hasPrediction: true}, want: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.value.decisionValue(); got != tt.want {
+				t.Errorf("decisionValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,106 +5,149 @@ package jitsiautoscaler
 
 import (
 	"context"
-	"math"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	promapi "github.com/prometheus/client_golang/api"
+	promv1api "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 )
 
 const (
-	promRangeStep      = 15 * time.Minute
-	promRangeStart     = 15 * time.Minute
-	promRequestTimeout = 30 * time.Second
+	promRangeStep  = 15 * time.Minute
+	promRangeStart = 15 * time.Minute
 )
 
 const (
 	promCPURequest         = `rate(container_cpu_usage_seconds_total{container="jvb", id=~"/kubelet.*"}[5m])`
 	promConferenceRequest  = `jitsi_conferences{job=~"exporter-jvb-.*"}`
 	promParticipantRequest = `jitsi_participants{job=~"exporter-jvb-.*"}`
+	promStressLevelRequest = `jitsi_stress_level{job=~"exporter-jvb-.*"}`
+	promDownloadRequest    = `jitsi_bit_rate_download{job=~"exporter-jvb-.*"}`
+	promUploadRequest      = `jitsi_bit_rate_upload{job=~"exporter-jvb-.*"}`
 )
 
-func (p *prom) Scale() {
-	ctx, cancel := context.WithTimeout(p.ctx, promRequestTimeout)
-	defer cancel()
-	p.ctx = ctx
-	target := float64(p.Spec.Metric.TargetAverageUtilization)
-	avg := p.getAvgValueForMetric(p.Spec.Metric.Name)
-	if target > avg {
-		desiredReplicas := int32(math.RoundToEven(avg / target))
-		if err := p.scaleDown(desiredReplicas); err != nil {
-			p.log.Info("can't scale down", "error", err)
+const (
+	defaultTenantHeader = "X-Scope-OrgID"
+	thanosTenantHeader  = "THANOS-TENANT"
+)
+
+// prom queries the Prometheus query API, which is also served by VictoriaMetrics and Thanos.
+type prom struct {
+	apiv1     promv1api.API
+	timeRange promv1api.Range
+}
+
+func newPromProvider(cfg providerConfig) (MetricsProvider, error) {
+	spec := v1alpha1.PrometheusAPI{}
+	if cfg.jas.Spec.Prometheus != nil {
+		spec = *cfg.jas.Spec.Prometheus
+	}
+	pathPrefix := spec.PathPrefix
+	if spec.Tenant != "" {
+		switch {
+		case cfg.jas.Spec.MonitoringType == v1alpha1.MonitoringVictoriaMetrics && pathPrefix == "":
+			pathPrefix = fmt.Sprintf("/select/%s/prometheus", spec.Tenant)
+		case spec.TenantHeader != "":
+			cfg.creds.headers[spec.TenantHeader] = spec.Tenant
+		case cfg.jas.Spec.MonitoringType == v1alpha1.MonitoringThanos:
+			cfg.creds.headers[thanosTenantHeader] = spec.Tenant
+		default:
+			cfg.creds.headers[defaultTenantHeader] = spec.Tenant
 		}
-		return
 	}
-	desiredReplicas := int32(math.RoundToEven(avg / target))
-	if err := p.scaleUp(desiredReplicas); err != nil {
-		p.log.Info("can't scale up", "error", err)
+	promClient, err := promapi.NewClient(promapi.Config{
+		Address:      strings.TrimSuffix(cfg.jas.Spec.Host, "/") + pathPrefix,
+		RoundTripper: newAuthRoundTripper(cfg.transport, cfg.creds, true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &prom{
+		apiv1: promv1api.NewAPI(promClient),
+		timeRange: promv1api.Range{
+			Start: time.Now().Add(-promRangeStart),
+			End:   time.Now(),
+			Step:  promRangeStep,
+		},
+	}, nil
+}
+
+func (p *prom) Samples(ctx context.Context, metric v1alpha1.Metric) ([]sample, v1alpha1.Reduction, error) {
+	request, reduction, err := promRequestOf(metric)
+	if err != nil {
+		return nil, "", err
 	}
+	samples, err := p.latestSamples(ctx, request)
+	return samples, reduction, err
 }
 
-func (p *prom) getAvgValueForMetric(name v1alpha1.MetricName) float64 {
-	switch name {
+func promRequestOf(metric v1alpha1.Metric) (string, v1alpha1.Reduction, error) {
+	switch metric.Name {
 	case v1alpha1.ResourceCPU:
-		return p.countAvgValueByRequest(promCPURequest)
+		return promCPURequest, v1alpha1.ReductionAvg, nil
 	case v1alpha1.ResourceConference:
-		return p.countAvgValueByRequest(promConferenceRequest)
+		return promConferenceRequest, v1alpha1.ReductionAvg, nil
 	case v1alpha1.ResourceParticipants:
-		return p.countAvgValueByRequest(promParticipantRequest)
+		return promParticipantRequest, v1alpha1.ReductionAvg, nil
+	case v1alpha1.ResourceStressLevel:
+		return promStressLevelRequest, v1alpha1.ReductionAvg, nil
+	case v1alpha1.ResourceBitRateDownload:
+		return promDownloadRequest, v1alpha1.ReductionAvg, nil
+	case v1alpha1.ResourceBitRateUpload:
+		return promUploadRequest, v1alpha1.ReductionAvg, nil
+	case v1alpha1.ResourceCustom:
+		if metric.Custom == nil {
+			return "", "", errCustomQueryNotSet
+		}
+		return metric.Custom.Query, metric.Custom.Reduction, nil
 	default:
-		return 0
+		return "", "", fmt.Errorf("%w: %s", errUnknownMetric, metric.Name)
 	}
 }
 
-func (p *prom) countAvgValueByRequest(request string) float64 {
-	result, _, err := p.apiv1.QueryRange(p.ctx, request, p.timeRange)
+// Series reduces the samples of all series returned by the query per timestamp.
+func (p *prom) Series(ctx context.Context, metric v1alpha1.Metric, start, end time.Time) ([]sample, error) {
+	request, reduction, err := promRequestOf(metric)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := p.apiv1.QueryRange(ctx, request, promv1api.Range{Start: start, End: end, Step: predictionStep})
 	if err != nil {
-		p.log.Info("can't query prometheus", "error", err)
-		return 1
+		return nil, err
 	}
-	var sum model.SampleValue
 	results, ok := result.(model.Matrix)
 	if !ok {
-		return 0
+		return nil, errNoMetricData
 	}
-	for res := range results {
-		sum = +results[res].Values[1].Value
-	}
-	return float64(sum / model.SampleValue(len(results)))
-}
-
-func (p *prom) scaleUp(desiredReplicas int32) error {
-	jitsi, getErr := getJVBCR(p.ctx, p.Client, p.Spec.ScaleTargetRef.Name, p.Namespace)
-	if getErr != nil {
-		return getErr
-	}
-	jitsi.Spec.Replicas *= desiredReplicas
-	if jitsi.Spec.Replicas > p.Spec.MaxReplicas {
-		jitsi.Spec.Replicas = p.Spec.MaxReplicas
+	var samples []sample
+	for _, series := range results {
+		for _, value := range series.Values {
+			samples = append(samples, sample{timestamp: value.Timestamp.Time(), value: float64(value.Value)})
+		}
 	}
-	return p.Update(p.ctx, jitsi)
+	return reduceByTimestamp(samples, reduction), nil
 }
 
-func (p *prom) scaleDown(desiredReplicas int32) error {
-	jitsi, getErr := getJVBCR(p.ctx, p.Client, p.Spec.ScaleTargetRef.Name, p.Namespace)
-	if getErr != nil {
-		return getErr
-	}
-	jitsi.Spec.Replicas = desiredReplicas
-	if jitsi.Spec.Replicas < p.Spec.MinReplicas {
-		jitsi.Spec.Replicas = p.Spec.MinReplicas
+// latestSamples returns the latest sample of every series returned by the query.
+func (p *prom) latestSamples(ctx context.Context, request string) ([]sample, error) {
+	result, _, err := p.apiv1.QueryRange(ctx, request, p.timeRange)
+	if err != nil {
+		return nil, err
 	}
-	return p.Update(p.ctx, jitsi)
-}
-
-func (p *prom) Repeat() time.Duration {
-	if p.Spec.Interval == "" {
-		return defaultRepeatInterval
+	results, ok := result.(model.Matrix)
+	if !ok {
+		return nil, errNoMetricData
 	}
-	interval, err := time.ParseDuration(p.Spec.Interval)
-	if err != nil {
-		p.log.Info("can't parse duration", "error", err)
-		return defaultRepeatInterval
+	samples := make([]sample, 0, len(results))
+	for _, series := range results {
+		if len(series.Values) == 0 {
+			continue
+		}
+		latest := series.Values[len(series.Values)-1]
+		samples = append(samples, sample{timestamp: latest.Timestamp.Time(), value: float64(latest.Value)})
 	}
-	return interval
+	return samples, nil
 }
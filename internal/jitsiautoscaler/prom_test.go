@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+)
+
+const promMatrixResponse = `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"pod":"jvb-0"},"values":[[1700000000,"1"],[1700000900,"3"]]},
+{"metric":{"pod":"jvb-1"},"values":[[1700000900,"5"]]},
+{"metric":{"pod":"jvb-2"},"values":[]}]}}`
+
+// promRequest is what the fake query API received.
+type promRequest struct {
+	path       string
+	header     http.Header
+	query      string
+	start, end float64
+	step       string
+}
+
+func newPromServer(t *testing.T, status int, body string) (*httptest.Server, *promRequest) {
+	t.Helper()
+	received := &promRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("can't parse query request: %v", err)
+		}
+		received.path, received.header = r.URL.Path, r.Header.Clone()
+		received.query, received.step = r.Form.Get("query"), r.Form.Get("step")
+		received.start, _ = strconv.ParseFloat(r.Form.Get("start"), 64)
+		received.end, _ = strconv.ParseFloat(r.Form.Get("end"), 64)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func newTestPromProvider(t *testing.T, monitoringType, host string, spec *v1alpha1.PrometheusAPI,
+	creds credentials) MetricsProvider {
+	t.Helper()
+	if creds.headers == nil {
+		creds.headers = map[string]string{}
+	}
+	jas := &v1alpha1.AutoScaler{Spec: v1alpha1.AutoScalerSpec{MonitoringType: monitoringType, Host: host, Prometheus: spec}}
+	provider, err := newPromProvider(providerConfig{jas: jas, log: logr.Discard(), creds: creds, transport: http.DefaultTransport})
+	if err != nil {
+		t.Fatalf("can't create provider: %v", err)
+	}
+	return provider
+}
+
+func TestPromProviderRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		monitoringType string
+		spec           *v1alpha1.PrometheusAPI
+		creds          credentials
+		wantPath       string
+		wantHeaders    map[string]string
+		absentHeaders  []string
+	}{
+		{
+			name:           "prometheus with bearer token",
+			monitoringType: v1alpha1.MonitoringPrometheus,
+			creds:          credentials{token: "secret"},
+			wantPath:       "/api/v1/query_range",
+			wantHeaders:    map[string]string{"Authorization": "Bearer secret"},
+			absentHeaders:  []string{defaultTenantHeader, thanosTenantHeader},
+		},
+		{
+			name:           "prometheus with basic auth and tenant",
+			monitoringType: v1alpha1.MonitoringPrometheus,
+			spec:           &v1alpha1.PrometheusAPI{PathPrefix: "/prom", Tenant: "team"},
+			creds:          credentials{login: "user", password: "pass"},
+			wantPath:       "/prom/api/v1/query_range",
+			wantHeaders:    map[string]string{"Authorization": "Basic dXNlcjpwYXNz", defaultTenantHeader: "team"},
+		},
+		{
+			name:           "prometheus keeps a custom authorization header",
+			monitoringType: v1alpha1.MonitoringPrometheus,
+			creds:          credentials{token: "secret", headers: map[string]string{"Authorization": "Custom value"}},
+			wantPath:       "/api/v1/query_range",
+			wantHeaders:    map[string]string{"Authorization": "Custom value"},
+		},
+		{
+			name:           "victoriametrics tenant in path",
+			monitoringType: v1alpha1.MonitoringVictoriaMetrics,
+			spec:           &v1alpha1.PrometheusAPI{Tenant: "42"},
+			wantPath:       "/select/42/prometheus/api/v1/query_range",
+			absentHeaders:  []string{"Authorization", defaultTenantHeader},
+		},
+		{
+			name:           "victoriametrics tenant with path prefix",
+			monitoringType: v1alpha1.MonitoringVictoriaMetrics,
+			spec:           &v1alpha1.PrometheusAPI{PathPrefix: "/select/0/prometheus", Tenant: "42"},
+			wantPath:       "/select/0/prometheus/api/v1/query_range",
+			wantHeaders:    map[string]string{defaultTenantHeader: "42"},
+		},
+		{
+			name:           "thanos tenant header",
+			monitoringType: v1alpha1.MonitoringThanos,
+			spec:           &v1alpha1.PrometheusAPI{Tenant: "team"},
+			creds:          credentials{token: "secret"},
+			wantPath:       "/api/v1/query_range",
+			wantHeaders:    map[string]string{thanosTenantHeader: "team", "Authorization": "Bearer secret"},
+			absentHeaders:  []string{defaultTenantHeader},
+		},
+		{
+			name:           "thanos custom tenant header",
+			monitoringType: v1alpha1.MonitoringThanos,
+			spec:           &v1alpha1.PrometheusAPI{Tenant: "team", TenantHeader: "X-Tenant"},
+			wantPath:       "/api/v1/query_range",
+			wantHeaders:    map[string]string{"X-Tenant": "team"},
+			absentHeaders:  []string{thanosTenantHeader},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newPromServer(t, http.StatusOK, promMatrixResponse)
+			provider := newTestPromProvider(t, tt.monitoringType, server.URL+"/", tt.spec, tt.creds)
+			before := time.Now()
+			if _, _, err := provider.Samples(context.Background(), v1alpha1.Metric{Name: v1alpha1.ResourceConference}); err != nil {
+				t.Fatalf("Samples() error = %v", err)
+			}
+			if received.path != tt.wantPath {
+				t.Errorf("path = %q, want %q", received.path, tt.wantPath)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := received.header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+			for _, name := range tt.absentHeaders {
+				if got := received.header.Get(name); got != "" {
+					t.Errorf("header %s = %q, want it unset", name, got)
+				}
+			}
+			if received.query != promConferenceRequest {
+				t.Errorf("query = %q, want %q", received.query, promConferenceRequest)
+			}
+			if received.step != strconv.Itoa(int(promRangeStep.Seconds())) {
+				t.Errorf("step = %q, want %v", received.step, promRangeStep.Seconds())
+			}
+			if window := received.end - received.start; math.Abs(window-promRangeStart.Seconds()) > 1 {
+				t.Errorf("range = %vs, want %vs", window, promRangeStart.Seconds())
+			}
+			if received.end > float64(before.Unix()+1) {
+				t.Errorf("end = %v, want it before %v", received.end, before.Unix())
+			}
+		})
+	}
+}
+
+func TestPromProviderSamples(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		metric    v1alpha1.Metric
+		want      []float64
+		reduction v1alpha1.Reduction
+		wantErr   string
+	}{
+		{
+			name:      "latest sample of every series",
+			status:    http.StatusOK,
+			body:      promMatrixResponse,
+			metric:    v1alpha1.Metric{Name: v1alpha1.ResourceParticipants},
+			want:      []float64{3, 5},
+			reduction: v1alpha1.ReductionAvg,
+		},
+		{
+			name:   "custom query and reduction",
+			status: http.StatusOK,
+			body:   promMatrixResponse,
+			metric: v1alpha1.Metric{Name: v1alpha1.ResourceCustom,
+				Custom: &v1alpha1.CustomMetric{Query: "max(jitsi_largest_conference)", Reduction: v1alpha1.ReductionMax}},
+			want:      []float64{3, 5},
+			reduction: v1alpha1.ReductionMax,
+		},
+		{
+			name:      "empty result",
+			status:    http.StatusOK,
+			body:      `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			metric:    v1alpha1.Metric{Name: v1alpha1.ResourceConference},
+			want:      []float64{},
+			reduction: v1alpha1.ReductionAvg,
+		},
+		{
+			name:    "not a matrix",
+			status:  http.StatusOK,
+			body:    `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`,
+			metric:  v1alpha1.Metric{Name: v1alpha1.ResourceConference},
+			wantErr: errNoMetricData.Error(),
+		},
+		{
+			name:    "bad query",
+			status:  http.StatusBadRequest,
+			body:    `{"status":"error","errorType":"bad_data","error":"parse error at char 1"}`,
+			metric:  v1alpha1.Metric{Name: v1alpha1.ResourceConference},
+			wantErr: "parse error at char 1",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			body:    `{"status":"error","errorType":"internal","error":"storage unavailable"}`,
+			metric:  v1alpha1.Metric{Name: v1alpha1.ResourceConference},
+			wantErr: "500",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newPromServer(t, tt.status, tt.body)
+			provider := newTestPromProvider(t, v1alpha1.MonitoringPrometheus, server.URL, nil, credentials{})
+			samples, reduction, err := provider.Samples(context.Background(), tt.metric)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Samples() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Samples() error = %v", err)
+			}
+			if tt.metric.Custom != nil && received.query != tt.metric.Custom.Query {
+				t.Errorf("query = %q, want %q", received.query, tt.metric.Custom.Query)
+			}
+			if reduction != tt.reduction {
+				t.Errorf("reduction = %q, want %q", reduction, tt.reduction)
+			}
+			if got := sampleValues(samples); !equalValues(got, tt.want) {
+				t.Errorf("samples = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromProviderSeries(t *testing.T) {
+	server, received := newPromServer(t, http.StatusOK, promMatrixResponse)
+	provider := newTestPromProvider(t, v1alpha1.MonitoringPrometheus, server.URL, nil, credentials{})
+	start, end := time.Unix(1700000000, 0), time.Unix(1700000900, 0)
+	series, err := provider.Series(context.Background(), v1alpha1.Metric{Name: v1alpha1.ResourceConference}, start, end)
+	if err != nil {
+		t.Fatalf("Series() error = %v", err)
+	}
+	if received.start != float64(start.Unix()) || received.end != float64(end.Unix()) {
+		t.Errorf("range = %v-%v, want %v-%v", received.start, received.end, start.Unix(), end.Unix())
+	}
+	if received.step != strconv.Itoa(int(predictionStep.Seconds())) {
+		t.Errorf("step = %q, want %v", received.step, predictionStep.Seconds())
+	}
+	// the samples of all series are averaged per timestamp
+	if got, want := sampleValues(series), []float64{1, 4}; !equalValues(got, want) {
+		t.Errorf("series = %v, want %v", got, want)
+	}
+}
+
+func sampleValues(samples []sample) []float64 {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		values = append(values, s.value)
+	}
+	return values
+}
+
+func equalValues(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
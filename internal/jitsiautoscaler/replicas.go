@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// defaultTolerance is the relative deviation of the metric from its target
+// within which no rescale happens, same as the HPA default.
+const defaultTolerance = 0.1
+
+// ReplicaCalculator computes the desired replica count of a scale target
+// from its current replica count and the observed metric value.
+type ReplicaCalculator interface {
+	DesiredReplicas(currentReplicas int32, avg, target float64) int32
+}
+
+// proportionalCalculator implements the HPA algorithm:
+// desired = ceil(current * avg / target), skipped while avg/target is within tolerance.
+type proportionalCalculator struct {
+	tolerance float64
+}
+
+func newReplicaCalculator() ReplicaCalculator {
+	return &proportionalCalculator{tolerance: defaultTolerance}
+}
+
+func (c *proportionalCalculator) DesiredReplicas(currentReplicas int32, avg, target float64) int32 {
+	if currentReplicas <= 0 || target <= 0 || avg < 0 {
+		return currentReplicas
+	}
+	ratio := avg / target
+	if math.Abs(ratio-1) <= c.tolerance {
+		return currentReplicas
+	}
+	return int32(math.Ceil(float64(currentReplicas) * ratio))
+}
+
+// limitReplicas keeps desired within [minReplicas, maxReplicas], maxReplicas of 0 means no upper limit.
+func limitReplicas(desired, minReplicas, maxReplicas int32) int32 {
+	if minReplicas < 1 {
+		minReplicas = 1
+	}
+	if maxReplicas > 0 && desired > maxReplicas {
+		return maxReplicas
+	}
+	if desired < minReplicas {
+		return minReplicas
+	}
+	return desired
+}
+
+func (a *autoscaler) scale(ctx context.Context, values []metricValue) error {
+	jas := a.AutoScaler
+	now := time.Now()
+	replicaRange := scheduledReplicaRange(jas, a.log, now)
+	jas.Status.ActiveSchedules = replicaRange.activeSchedules
+	target, getErr := getScaleTarget(ctx, a.Client, jas)
+	if getErr != nil {
+		setCondition(jas, v1alpha1.AbleToScale, metav1.ConditionFalse, reasonFailedGetScale, getErr.Error())
+		a.event(nil, corev1.EventTypeWarning, reasonFailedGetScale, getErr.Error())
+		return getErr
+	}
+	currentReplicas := target.replicas()
+	jas.Status.CurrentReplicas = currentReplicas
+	calculatedReplicas, queryErr := recommendReplicas(jas, a.calculator, currentReplicas, values)
+	if queryErr != nil {
+		a.event(target, corev1.EventTypeWarning, reasonFailedGetMetrics, queryErr.Error())
+	}
+	desiredReplicas := limitReplicas(calculatedReplicas, replicaRange.minReplicas, replicaRange.maxReplicas)
+	if calculatedReplicas > desiredReplicas {
+		a.event(target, corev1.EventTypeNormal, eventLimitedByMaxReplicas,
+			fmt.Sprintf("the desired replica count %d is more than the maximum replica count %d", calculatedReplicas, desiredReplicas))
+	}
+	stabilizedReplicas := desiredReplicas
+	if queryErr != nil {
+		// without metrics only the replica range, e.g. of an active schedule, is enforced
+		if desiredReplicas == currentReplicas {
+			return queryErr
+		}
+	} else {
+		stabilizedReplicas = applyBehavior(jas, currentReplicas, desiredReplicas, now)
+		stabilizedReplicas = limitReplicas(stabilizedReplicas, replicaRange.minReplicas, replicaRange.maxReplicas)
+	}
+	setScalingLimited(jas, calculatedReplicas, desiredReplicas, stabilizedReplicas)
+	jas.Status.DesiredReplicas = stabilizedReplicas
+	if stabilizedReplicas == currentReplicas {
+		setCondition(jas, v1alpha1.AbleToScale, metav1.ConditionTrue, reasonReadyForNewScale,
+			"recommended size matches current size")
+		return nil
+	}
+	if jas.Spec.Mode == v1alpha1.ModeDryRun {
+		message := fmt.Sprintf("dry run, the target scale would be updated from %d to %d", currentReplicas, stabilizedReplicas)
+		setCondition(jas, v1alpha1.AbleToScale, metav1.ConditionTrue, reasonDryRun, message)
+		a.event(nil, corev1.EventTypeNormal, reasonDryRun, message)
+		return queryErr
+	}
+	if err := target.setReplicas(ctx, a.Client, stabilizedReplicas); err != nil {
+		setCondition(jas, v1alpha1.AbleToScale, metav1.ConditionFalse, reasonFailedUpdateScale, err.Error())
+		a.event(target, corev1.EventTypeWarning, eventFailedRescale,
+			fmt.Sprintf("New size: %d; reason: %s; error: %v", stabilizedReplicas,
+				rescaleReason(jas, calculatedReplicas, desiredReplicas, currentReplicas, stabilizedReplicas), err))
+		return err
+	}
+	a.event(target, corev1.EventTypeNormal, eventSuccessfulRescale,
+		fmt.Sprintf("New size: %d; reason: %s", stabilizedReplicas,
+			rescaleReason(jas, calculatedReplicas, desiredReplicas, currentReplicas, stabilizedReplicas)))
+	recordScaleEvent(jas, stabilizedReplicas-currentReplicas, now)
+	jas.Status.LastScaleTime = ptr.To(metav1.NewTime(now))
+	setCondition(jas, v1alpha1.AbleToScale, metav1.ConditionTrue, reasonSucceededRescale,
+		fmt.Sprintf("the autoscaler was able to update the target scale to %d", stabilizedReplicas))
+	return queryErr
+}
+
+// event records the event on the autoscaler and, if it is known, on the scale target.
+func (a *autoscaler) event(target *scaleTarget, eventType, reason, message string) {
+	a.recorder.Event(a.AutoScaler, eventType, reason, message)
+	if target != nil {
+		a.recorder.Event(target.object, eventType, reason, message)
+	}
+}
+
+// rescaleReason describes why the replica count changed, in the words of the HPA.
+func rescaleReason(jas *v1alpha1.AutoScaler, calculatedReplicas, desiredReplicas, currentReplicas, newReplicas int32) string {
+	switch {
+	case calculatedReplicas < desiredReplicas:
+		return "Current number of replicas below minimum replica count"
+	case calculatedReplicas > desiredReplicas:
+		return "Current number of replicas above maximum replica count"
+	case newReplicas < currentReplicas:
+		return "All metrics below target"
+	}
+	var top *v1alpha1.MetricStatus
+	for i := range jas.Status.CurrentMetrics {
+		metric := &jas.Status.CurrentMetrics[i]
+		if metric.Error == "" && (top == nil || metric.DesiredReplicas > top.DesiredReplicas) {
+			top = metric
+		}
+	}
+	if top == nil {
+		return "metrics unavailable"
+	}
+	return fmt.Sprintf("%s above target", top.Name)
+}
+
+func setScalingLimited(jas *v1alpha1.AutoScaler, calculatedReplicas, desiredReplicas, stabilizedReplicas int32) {
+	switch {
+	case calculatedReplicas > desiredReplicas:
+		setCondition(jas, v1alpha1.ScalingLimited, metav1.ConditionTrue, reasonTooManyReplicas,
+			"the desired replica count is more than the maximum replica count")
+	case calculatedReplicas < desiredReplicas:
+		setCondition(jas, v1alpha1.ScalingLimited, metav1.ConditionTrue, reasonTooFewReplicas,
+			"the desired replica count is less than the minimum replica count")
+	case desiredReplicas != stabilizedReplicas:
+		setCondition(jas, v1alpha1.ScalingLimited, metav1.ConditionTrue, reasonScalingPolicyLimit,
+			"the desired replica count is limited by the scaling behavior")
+	default:
+		setCondition(jas, v1alpha1.ScalingLimited, metav1.ConditionFalse, reasonDesiredWithinRange, messageDesiredWithinRange)
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import "testing"
+
+func TestDesiredReplicas(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentReplicas int32
+		avg, target     float64
+		want            int32
+	}{
+		{name: "on target", currentReplicas: 4, avg: 10, target: 10, want: 4},
+		{name: "within tolerance above", currentReplicas: 10, avg: 10.9, target: 10, want: 10},
+		{name: "within tolerance below", currentReplicas: 10, avg: 9.1, target: 10, want: 10},
+		{name: "above tolerance", currentReplicas: 10, avg: 11.2, target: 10, want: 12},
+		{name: "below tolerance", currentReplicas: 10, avg: 8.8, target: 10, want: 9},
+		{name: "far below tolerance", currentReplicas: 4, avg: 5, target: 10, want: 2},
+		{name: "rounded up", currentReplicas: 3, avg: 14, target: 10, want: 5},
+		{name: "rounded up to one", currentReplicas: 3, avg: 1, target: 10, want: 1},
+		{name: "zero load", currentReplicas: 3, avg: 0, target: 10, want: 0},
+		{name: "doubled load", currentReplicas: 3, avg: 20, target: 10, want: 6},
+		{name: "no current replicas", currentReplicas: 0, avg: 20, target: 10, want: 0},
+		{name: "negative current replicas", currentReplicas: -1, avg: 20, target: 10, want: -1},
+		{name: "zero target", currentReplicas: 3, avg: 20, target: 0, want: 3},
+		{name: "negative target", currentReplicas: 3, avg: 20, target: -10, want: 3},
+		{name: "negative avg", currentReplicas: 3, avg: -20, target: 10, want: 3},
+	}
+	calculator := newReplicaCalculator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculator.DesiredReplicas(tt.currentReplicas, tt.avg, tt.target); got != tt.want {
+				t.Errorf("DesiredReplicas(%d, %v, %v) = %d, want %d", tt.currentReplicas, tt.avg, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitReplicas(t *testing.T) {
+	tests := []struct {
+		name                              string
+		desired, minReplicas, maxReplicas int32
+		want                              int32
+	}{
+		{name: "within range", desired: 3, minReplicas: 1, maxReplicas: 5, want: 3},
+		{name: "below min", desired: 1, minReplicas: 2, maxReplicas: 5, want: 2},
+		{name: "above max", desired: 7, minReplicas: 1, maxReplicas: 5, want: 5},
+		{name: "equal to min", desired: 2, minReplicas: 2, maxReplicas: 5, want: 2},
+		{name: "equal to max", desired: 5, minReplicas: 1, maxReplicas: 5, want: 5},
+		{name: "no upper limit", desired: 100, minReplicas: 1, maxReplicas: 0, want: 100},
+		{name: "no upper limit below min", desired: 1, minReplicas: 3, maxReplicas: 0, want: 3},
+		{name: "min raised to one", desired: 0, minReplicas: 0, maxReplicas: 5, want: 1},
+		{name: "negative min raised to one", desired: -2, minReplicas: -1, maxReplicas: 0, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limitReplicas(tt.desired, tt.minReplicas, tt.maxReplicas); got != tt.want {
+				t.Errorf("limitReplicas(%d, %d, %d) = %d, want %d", tt.desired, tt.minReplicas, tt.maxReplicas, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // time zones of the schedules must resolve in distroless images
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	errScheduleDurationNotSet = errors.New("schedule with cron requires a duration")
+	errScheduleNotBounded     = errors.New("schedule requires either a cron or a start and an end")
+)
+
+// replicaRange is the replica range the autoscaler decides within.
+type replicaRange struct {
+	minReplicas, maxReplicas int32
+	activeSchedules          []string
+	// nextChange is the next time one of the schedules starts or stops, zero if none will.
+	nextChange time.Time
+}
+
+// scheduledReplicaRange applies the active schedules on top of the spec replica range. When several
+// schedules are active at once the highest minReplicas and the highest maxReplicas win.
+// Invalid schedules are logged and skipped.
+func scheduledReplicaRange(jas *v1alpha1.AutoScaler, l logr.Logger, now time.Time) replicaRange {
+	r := replicaRange{minReplicas: jas.Spec.MinReplicas, maxReplicas: jas.Spec.MaxReplicas}
+	var scheduledMin, scheduledMax *int32
+	for _, schedule := range jas.Spec.Schedules {
+		active, change, err := scheduleState(schedule, now)
+		if err != nil {
+			l.Info("can't evaluate schedule", "schedule", schedule.Name, "error", err)
+			continue
+		}
+		if !change.IsZero() && (r.nextChange.IsZero() || change.Before(r.nextChange)) {
+			r.nextChange = change
+		}
+		if !active {
+			continue
+		}
+		r.activeSchedules = append(r.activeSchedules, schedule.Name)
+		if schedule.MinReplicas != nil && (scheduledMin == nil || *schedule.MinReplicas > *scheduledMin) {
+			scheduledMin = schedule.MinReplicas
+		}
+		if schedule.MaxReplicas != nil && (scheduledMax == nil || *schedule.MaxReplicas > *scheduledMax) {
+			scheduledMax = schedule.MaxReplicas
+		}
+	}
+	if scheduledMin != nil {
+		r.minReplicas = *scheduledMin
+	}
+	if scheduledMax != nil {
+		r.maxReplicas = *scheduledMax
+	}
+	if r.maxReplicas > 0 && r.minReplicas > r.maxReplicas {
+		r.maxReplicas = r.minReplicas
+	}
+	return r
+}
+
+// scheduleState reports whether the schedule is active at now and when this will change next.
+func scheduleState(schedule v1alpha1.Schedule, now time.Time) (bool, time.Time, error) {
+	if schedule.Start != nil && now.Before(schedule.Start.Time) {
+		return false, schedule.Start.Time, nil
+	}
+	if schedule.End != nil && !now.Before(schedule.End.Time) {
+		return false, time.Time{}, nil
+	}
+	if schedule.Cron == "" {
+		if schedule.Start == nil || schedule.End == nil {
+			return false, time.Time{}, errScheduleNotBounded
+		}
+		return true, schedule.End.Time, nil
+	}
+	if schedule.Duration == nil || schedule.Duration.Duration <= 0 {
+		return false, time.Time{}, errScheduleDurationNotSet
+	}
+	location := time.UTC
+	if schedule.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return false, time.Time{}, err
+		}
+	}
+	spec, err := cron.ParseStandard(schedule.Cron)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid cron %q: %w", schedule.Cron, err)
+	}
+	duration := schedule.Duration.Duration
+	activation := spec.Next(now.Add(-duration).In(location))
+	if activation.IsZero() {
+		return false, time.Time{}, nil
+	}
+	if activation.After(now) {
+		return false, boundedChange(schedule, activation), nil
+	}
+	// overlapping activations extend the window until the last one ends
+	end := activation.Add(duration)
+	for next := spec.Next(activation); !next.IsZero() && !next.After(now); next = spec.Next(next) {
+		end = next.Add(duration)
+	}
+	return true, boundedChange(schedule, end), nil
+}
+
+func boundedChange(schedule v1alpha1.Schedule, change time.Time) time.Time {
+	if schedule.End != nil && schedule.End.Time.Before(change) {
+		return schedule.End.Time
+	}
+	return change
+}
+
+// repeatAfter returns the spec interval shortened to the interval of the behavior and the next schedule change,
+// so that capacity is pre-warmed in time.
+func repeatAfter(jas *v1alpha1.AutoScaler, l logr.Logger, now time.Time) time.Duration {
+	interval := defaultRepeatInterval
+	if jas.Spec.Interval != "" {
+		parsed, err := time.ParseDuration(jas.Spec.Interval)
+		if err != nil {
+			l.Info("can't parse duration", "error", err)
+		} else {
+			interval = parsed
+		}
+	}
+	if limit := behaviorInterval(jas.Spec.Behavior); limit > 0 {
+		interval = min(interval, limit)
+	}
+	nextChange := scheduledReplicaRange(jas, logr.Discard(), now).nextChange
+	if nextChange.IsZero() {
+		return interval
+	}
+	return max(min(interval, nextChange.Sub(now)), time.Second)
+}
@@ -0,0 +1,266 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func metaTime(value string) *metav1.Time {
+	return &metav1.Time{Time: utc(value)}
+}
+
+func hours(h int) *metav1.Duration {
+	return &metav1.Duration{Duration: time.Duration(h) * time.Hour}
+}
+
+func TestScheduleState(t *testing.T) {
+	berlinMonday := v1alpha1.Schedule{Name: "monday", Cron: "45 8 * * 1", TimeZone: "Europe/Berlin", Duration: hours(2)}
+	tests := []struct {
+		name       string
+		schedule   v1alpha1.Schedule
+		now        string
+		wantActive bool
+		wantChange string
+		wantErr    error
+	}{
+		{
+			name:       "cron before the activation",
+			schedule:   v1alpha1.Schedule{Cron: "45 8 * * 1", Duration: hours(2)},
+			now:        "2026-01-05T08:00:00Z",
+			wantChange: "2026-01-05T08:45:00Z",
+		},
+		{
+			name:       "cron within the window",
+			schedule:   v1alpha1.Schedule{Cron: "45 8 * * 1", Duration: hours(2)},
+			now:        "2026-01-05T09:00:00Z",
+			wantActive: true,
+			wantChange: "2026-01-05T10:45:00Z",
+		},
+		{
+			name:       "cron at the activation",
+			schedule:   v1alpha1.Schedule{Cron: "45 8 * * 1", Duration: hours(2)},
+			now:        "2026-01-05T08:45:00Z",
+			wantActive: true,
+			wantChange: "2026-01-05T10:45:00Z",
+		},
+		{
+			name:       "cron after the window",
+			schedule:   v1alpha1.Schedule{Cron: "45 8 * * 1", Duration: hours(2)},
+			now:        "2026-01-05T10:45:00Z",
+			wantChange: "2026-01-12T08:45:00Z",
+		},
+		{
+			name:       "time zone before the activation",
+			schedule:   berlinMonday,
+			now:        "2026-01-05T07:30:00Z",
+			wantChange: "2026-01-05T07:45:00Z",
+		},
+		{
+			name:       "time zone within the window",
+			schedule:   berlinMonday,
+			now:        "2026-01-05T08:00:00Z",
+			wantActive: true,
+			wantChange: "2026-01-05T09:45:00Z",
+		},
+		{
+			name:       "summer time",
+			schedule:   berlinMonday,
+			now:        "2026-03-30T07:00:00Z",
+			wantActive: true,
+			wantChange: "2026-03-30T08:45:00Z",
+		},
+		{
+			name:       "winter time after the transition",
+			schedule:   berlinMonday,
+			now:        "2026-10-26T06:50:00Z",
+			wantChange: "2026-10-26T07:45:00Z",
+		},
+		{
+			name:       "window across the transition to summer time",
+			schedule:   v1alpha1.Schedule{Cron: "30 1 * * 0", TimeZone: "Europe/Berlin", Duration: hours(2)},
+			now:        "2026-03-29T02:00:00Z",
+			wantActive: true,
+			wantChange: "2026-03-29T02:30:00Z",
+		},
+		{
+			name:       "overlapping activations",
+			schedule:   v1alpha1.Schedule{Cron: "0 * * * *", Duration: &metav1.Duration{Duration: 90 * time.Minute}},
+			now:        "2026-01-05T10:30:00Z",
+			wantActive: true,
+			wantChange: "2026-01-05T11:30:00Z",
+		},
+		{
+			name:       "before the start",
+			schedule:   v1alpha1.Schedule{Start: metaTime("2026-11-05T07:00:00Z"), End: metaTime("2026-11-05T19:00:00Z")},
+			now:        "2026-11-05T06:00:00Z",
+			wantChange: "2026-11-05T07:00:00Z",
+		},
+		{
+			name:       "within start and end",
+			schedule:   v1alpha1.Schedule{Start: metaTime("2026-11-05T07:00:00Z"), End: metaTime("2026-11-05T19:00:00Z")},
+			now:        "2026-11-05T07:00:00Z",
+			wantActive: true,
+			wantChange: "2026-11-05T19:00:00Z",
+		},
+		{
+			name:     "at the end",
+			schedule: v1alpha1.Schedule{Start: metaTime("2026-11-05T07:00:00Z"), End: metaTime("2026-11-05T19:00:00Z")},
+			now:      "2026-11-05T19:00:00Z",
+		},
+		{
+			name: "cron window bounded by the end",
+			schedule: v1alpha1.Schedule{Cron: "45 8 * * 1", Duration: hours(2),
+				End: metaTime("2026-01-05T09:00:00Z")},
+			now:        "2026-01-05T08:50:00Z",
+			wantActive: true,
+			wantChange: "2026-01-05T09:00:00Z",
+		},
+		{
+			name: "cron before the start",
+			schedule: v1alpha1.Schedule{Cron: "45 8 * * 1", Duration: hours(2),
+				Start: metaTime("2026-01-12T00:00:00Z")},
+			now:        "2026-01-05T09:00:00Z",
+			wantChange: "2026-01-12T00:00:00Z",
+		},
+		{
+			name:     "cron without duration",
+			schedule: v1alpha1.Schedule{Cron: "45 8 * * 1"},
+			now:      "2026-01-05T09:00:00Z",
+			wantErr:  errScheduleDurationNotSet,
+		},
+		{
+			name:     "without cron and end",
+			schedule: v1alpha1.Schedule{Start: metaTime("2026-11-05T07:00:00Z")},
+			now:      "2026-11-05T08:00:00Z",
+			wantErr:  errScheduleNotBounded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, change, err := scheduleState(tt.schedule, utc(tt.now))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("scheduleState() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scheduleState() error = %v", err)
+			}
+			if active != tt.wantActive {
+				t.Errorf("active = %v, want %v", active, tt.wantActive)
+			}
+			var wantChange time.Time
+			if tt.wantChange != "" {
+				wantChange = utc(tt.wantChange)
+			}
+			if !change.Equal(wantChange) {
+				t.Errorf("change = %v, want %v", change.UTC(), wantChange)
+			}
+		})
+	}
+}
+
+func TestScheduleStateInvalid(t *testing.T) {
+	for _, schedule := range []v1alpha1.Schedule{
+		{Cron: "not a cron", Duration: hours(1)},
+		{Cron: "45 8 * * 1", Duration: hours(1), TimeZone: "Europe/Nowhere"},
+	} {
+		if _, _, err := scheduleState(schedule, utc("2026-01-05T09:00:00Z")); err == nil {
+			t.Errorf("scheduleState(%+v) error = nil, want an error", schedule)
+		}
+	}
+}
+
+func TestScheduledReplicaRange(t *testing.T) {
+	allHands := v1alpha1.Schedule{Name: "all-hands", Cron: "45 8 * * 1", TimeZone: "Europe/Berlin", Duration: hours(2),
+		MinReplicas: ptr.To[int32](4), MaxReplicas: ptr.To[int32](10)}
+	conference := v1alpha1.Schedule{Name: "conference", Start: metaTime("2026-01-05T07:00:00Z"), End: metaTime("2026-01-05T19:00:00Z"),
+		MinReplicas: ptr.To[int32](6)}
+	tests := []struct {
+		name       string
+		schedules  []v1alpha1.Schedule
+		now        string
+		wantMin    int32
+		wantMax    int32
+		wantActive []string
+		wantChange string
+	}{
+		{
+			name:       "no active schedule",
+			schedules:  []v1alpha1.Schedule{allHands},
+			now:        "2026-01-05T07:00:00Z",
+			wantMin:    1,
+			wantMax:    5,
+			wantChange: "2026-01-05T07:45:00Z",
+		},
+		{
+			name:       "active schedule",
+			schedules:  []v1alpha1.Schedule{allHands},
+			now:        "2026-01-05T08:00:00Z",
+			wantMin:    4,
+			wantMax:    10,
+			wantActive: []string{"all-hands"},
+			wantChange: "2026-01-05T09:45:00Z",
+		},
+		{
+			name:       "min above the spec max",
+			schedules:  []v1alpha1.Schedule{conference},
+			now:        "2026-01-05T07:00:00Z",
+			wantMin:    6,
+			wantMax:    6,
+			wantActive: []string{"conference"},
+			wantChange: "2026-01-05T19:00:00Z",
+		},
+		{
+			name:       "overlapping schedules, the highest wins",
+			schedules:  []v1alpha1.Schedule{allHands, conference},
+			now:        "2026-01-05T08:00:00Z",
+			wantMin:    6,
+			wantMax:    10,
+			wantActive: []string{"all-hands", "conference"},
+			wantChange: "2026-01-05T09:45:00Z",
+		},
+		{
+			name:       "invalid schedule is skipped",
+			schedules:  []v1alpha1.Schedule{{Name: "invalid", Cron: "45 8 * * 1", MinReplicas: ptr.To[int32](8)}, allHands},
+			now:        "2026-01-05T08:00:00Z",
+			wantMin:    4,
+			wantMax:    10,
+			wantActive: []string{"all-hands"},
+			wantChange: "2026-01-05T09:45:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jas := &v1alpha1.AutoScaler{Spec: v1alpha1.AutoScalerSpec{MinReplicas: 1, MaxReplicas: 5, Schedules: tt.schedules}}
+			got := scheduledReplicaRange(jas, logr.Discard(), utc(tt.now))
+			if got.minReplicas != tt.wantMin || got.maxReplicas != tt.wantMax {
+				t.Errorf("range = %d-%d, want %d-%d", got.minReplicas, got.maxReplicas, tt.wantMin, tt.wantMax)
+			}
+			if !equalStrings(got.activeSchedules, tt.wantActive) {
+				t.Errorf("active schedules = %v, want %v", got.activeSchedules, tt.wantActive)
+			}
+			if !got.nextChange.Equal(utc(tt.wantChange)) {
+				t.Errorf("next change = %v, want %s", got.nextChange.UTC(), tt.wantChange)
+			}
+		})
+	}
+}
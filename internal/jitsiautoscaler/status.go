@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"math"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	reasonFailedGetMetrics    = "FailedGetMetrics"
+	reasonValidMetricFound    = "ValidMetricFound"
+	reasonFailedGetScale      = "FailedGetScale"
+	reasonFailedUpdateScale   = "FailedUpdateScale"
+	reasonSucceededRescale    = "SucceededRescale"
+	reasonDryRun              = "DryRun"
+	reasonReadyForNewScale    = "ReadyForNewScale"
+	reasonTooFewReplicas      = "TooFewReplicas"
+	reasonTooManyReplicas     = "TooManyReplicas"
+	reasonScalingPolicyLimit  = "ScalingPolicyLimit"
+	reasonDesiredWithinRange  = "DesiredWithinRange"
+	messageDesiredWithinRange = "the desired count is within the acceptable range"
+)
+
+const (
+	eventSuccessfulRescale    = "SuccessfulRescale"
+	eventFailedRescale        = "FailedRescale"
+	eventLimitedByMaxReplicas = "LimitedByMaxReplicas"
+)
+
+func setCondition(jas *v1alpha1.AutoScaler, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&jas.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: jas.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func toQuantity(value float64) *resource.Quantity {
+	return resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+}
+
+// setMetricsAvailable marks the autoscaler as active, queryErr holds the errors of the metrics
+// which could not be fetched while the others were available.
+func setMetricsAvailable(jas *v1alpha1.AutoScaler, queryErr error) {
+	jas.Status.LastQueryError = ""
+	if queryErr != nil {
+		jas.Status.LastQueryError = queryErr.Error()
+	}
+	setCondition(jas, v1alpha1.ScalingActive, metav1.ConditionTrue, reasonValidMetricFound,
+		"the autoscaler was able to successfully calculate a replica count")
+}
+
+func setMetricError(jas *v1alpha1.AutoScaler, err error) {
+	jas.Status.LastQueryError = err.Error()
+	setCondition(jas, v1alpha1.ScalingActive, metav1.ConditionFalse, reasonFailedGetMetrics, err.Error())
+}
+
+func updateStatus(ctx context.Context, c client.Client, jas *v1alpha1.AutoScaler) error {
+	return c.Status().Update(ctx, jas)
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	scaleSubresource  = "scale"
+	defaultTargetKind = "JVB"
+)
+
+// scaleTarget is the resource referenced by the AutoScaler together with its scale subresource.
+type scaleTarget struct {
+	object *unstructured.Unstructured
+	scale  *autoscalingv1.Scale
+}
+
+// getScaleTarget reads the scale subresource of the target, JVB is used if apiVersion and kind are not set.
+func getScaleTarget(ctx context.Context, c client.Client, jas *v1alpha1.AutoScaler) (*scaleTarget, error) {
+	ref := jas.Spec.ScaleTargetRef
+	gvk := v1beta1.GroupVersion.WithKind(defaultTargetKind)
+	if ref.APIVersion != "" {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		gvk = gv.WithKind(gvk.Kind)
+	}
+	if ref.Kind != "" {
+		gvk.Kind = ref.Kind
+	}
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(gvk)
+	object.SetNamespace(jas.Namespace)
+	object.SetName(ref.Name)
+	// the client reads subresources of an unstructured target only into unstructured objects
+	body := newUnstructuredScale()
+	if err := c.SubResource(scaleSubresource).Get(ctx, object, body); err != nil {
+		return nil, err
+	}
+	scale := &autoscalingv1.Scale{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(body.Object, scale); err != nil {
+		return nil, err
+	}
+	// the scale subresource carries the uid of the target, events are matched by it
+	object.SetUID(scale.UID)
+	return &scaleTarget{object: object, scale: scale}, nil
+}
+
+func newUnstructuredScale() *unstructured.Unstructured {
+	scale := &unstructured.Unstructured{}
+	scale.SetGroupVersionKind(autoscalingv1.SchemeGroupVersion.WithKind("Scale"))
+	return scale
+}
+
+func (t *scaleTarget) replicas() int32 {
+	return t.scale.Spec.Replicas
+}
+
+func (t *scaleTarget) setReplicas(ctx context.Context, c client.Client, replicas int32) error {
+	t.scale.Spec.Replicas = replicas
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(t.scale)
+	if err != nil {
+		return err
+	}
+	body := &unstructured.Unstructured{Object: content}
+	body.SetGroupVersionKind(autoscalingv1.SchemeGroupVersion.WithKind("Scale"))
+	return c.SubResource(scaleSubresource).Update(ctx, t.object, client.WithSubResourceBody(body))
+}
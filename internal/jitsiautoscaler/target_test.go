@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/onmetal/meeting-operator/apis/jitsi/v1beta1"
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeAPIServer serves the objects of the tests by their path and records the bodies of the updates.
+type fakeAPIServer struct {
+	mu       sync.Mutex
+	objects  map[string]interface{}
+	requests []*http.Request
+	updates  map[string][]byte
+}
+
+func newFakeClient(t *testing.T, objects map[string]interface{}) (client.Client, *fakeAPIServer) {
+	t.Helper()
+	api := &fakeAPIServer{objects: objects, updates: map[string][]byte{}}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(v1beta1.GroupVersion.WithKind(defaultTargetKind), meta.RESTScopeNamespace)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+	c, err := client.New(&rest.Config{Host: server.URL}, client.Options{Scheme: clientgoscheme.Scheme, Mapper: mapper})
+	if err != nil {
+		t.Fatalf("can't create client: %v", err)
+	}
+	return c, api
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	object, ok := s.objects[r.URL.Path]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(metav1.Status{
+			TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound,
+		})
+		return
+	}
+	if r.Method == http.MethodPut {
+		body, _ := io.ReadAll(r.Body)
+		s.updates[r.URL.Path] = body
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(object)
+}
+
+func (s *fakeAPIServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func testScale(name string, replicas int32, selector string) *autoscalingv1.Scale {
+	return &autoscalingv1.Scale{
+		TypeMeta:   metav1.TypeMeta{Kind: "Scale", APIVersion: autoscalingv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: "target-uid"},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		Status:     autoscalingv1.ScaleStatus{Replicas: replicas, Selector: selector},
+	}
+}
+
+func TestScaleTarget(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  v1alpha1.ScaleTargetRef
+		path string
+	}{
+		{
+			name: "default JVB",
+			ref:  v1alpha1.ScaleTargetRef{Name: "jvb"},
+			path: "/apis/jitsi.meeting.ko/v1beta1/namespaces/default/jvbs/jvb/scale",
+		},
+		{
+			name: "deployment",
+			ref:  v1alpha1.ScaleTargetRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "workers"},
+			path: "/apis/apps/v1/namespaces/default/deployments/workers/scale",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, api := newFakeClient(t, map[string]interface{}{tt.path: testScale(tt.ref.Name, 2, "app=jvb")})
+			jas := &v1alpha1.AutoScaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "jas", Namespace: "default"},
+				Spec:       v1alpha1.AutoScalerSpec{ScaleTargetRef: tt.ref},
+			}
+			target, err := getScaleTarget(context.Background(), c, jas)
+			if err != nil {
+				t.Fatalf("getScaleTarget() error = %v", err)
+			}
+			if got := target.replicas(); got != 2 {
+				t.Errorf("replicas() = %d, want 2", got)
+			}
+			if got := target.scale.Status.Selector; got != "app=jvb" {
+				t.Errorf("selector = %q, want %q", got, "app=jvb")
+			}
+			if got := target.object.GetUID(); got != "target-uid" {
+				t.Errorf("uid = %q, want %q", got, "target-uid")
+			}
+			if err := target.setReplicas(context.Background(), c, 5); err != nil {
+				t.Fatalf("setReplicas() error = %v", err)
+			}
+			if req := api.lastRequest(); req.Method != http.MethodPut || req.URL.Path != tt.path {
+				t.Errorf("update = %s %s, want PUT %s", req.Method, req.URL.Path, tt.path)
+			}
+			updated := &autoscalingv1.Scale{}
+			if err := json.Unmarshal(api.updates[tt.path], updated); err != nil {
+				t.Fatalf("can't decode the updated scale: %v", err)
+			}
+			if updated.Kind != "Scale" || updated.APIVersion != autoscalingv1.SchemeGroupVersion.String() {
+				t.Errorf("updated kind = %s %s, want autoscaling/v1 Scale", updated.APIVersion, updated.Kind)
+			}
+			if updated.Name != tt.ref.Name || updated.Spec.Replicas != 5 {
+				t.Errorf("updated scale = %s with %d replicas, want %s with 5", updated.Name, updated.Spec.Replicas, tt.ref.Name)
+			}
+		})
+	}
+}
+
+func TestScaleTargetNotFound(t *testing.T) {
+	c, _ := newFakeClient(t, map[string]interface{}{})
+	jas := &v1alpha1.AutoScaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "jas", Namespace: "default"},
+		Spec:       v1alpha1.AutoScalerSpec{ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "jvb"}},
+	}
+	if _, err := getScaleTarget(context.Background(), c, jas); err == nil {
+		t.Error("getScaleTarget() error = nil, want not found")
+	}
+}
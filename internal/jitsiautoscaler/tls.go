@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jitsiautoscaler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	"github.com/onmetal/meeting-operator/apis/jitsiautoscaler/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newTransport returns a copy of the default transport configured with the AutoScaler TLS settings.
+func newTransport(ctx context.Context, c client.Client, jas *v1alpha1.AutoScaler) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert //reason: default transport is always *http.Transport
+	if jas.Spec.TLS == nil {
+		return transport, nil
+	}
+	tlsConfig, err := newTLSConfig(ctx, c, jas.Namespace, jas.Spec.TLS)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+func newTLSConfig(ctx context.Context, c client.Client, namespace string, spec *v1alpha1.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         spec.ServerName,
+		InsecureSkipVerify: spec.InsecureSkipVerify, //nolint:gosec //reason: explicitly requested in the AutoScaler spec
+	}
+	if spec.CA != nil {
+		ca, err := secretOrConfigMapValue(ctx, c, namespace, spec.CA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, errInvalidCA
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if spec.ClientCertSecret != nil {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: spec.ClientCertSecret.Name}, secret); err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate from secret %s: %w", spec.ClientCertSecret.Name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func secretOrConfigMapValue(ctx context.Context, c client.Client, namespace string, ref *v1alpha1.SecretOrConfigMap) (string, error) {
+	switch {
+	case ref.Secret != nil:
+		return secretValue(ctx, c, namespace, ref.Secret)
+	case ref.ConfigMap != nil:
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.ConfigMap.Name}, cm); err != nil {
+			return "", err
+		}
+		value, ok := cm.Data[ref.ConfigMap.Key]
+		if !ok {
+			return "", fmt.Errorf("%w: %s/%s", errConfigMapKeyNotExist, ref.ConfigMap.Name, ref.ConfigMap.Key)
+		}
+		return value, nil
+	default:
+		return "", errInvalidCA
+	}
+}